@@ -1,18 +1,22 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
-	"regexp"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/Financial-Times/annotations-mapper/mapper"
 	"github.com/Financial-Times/go-logger"
 	"github.com/Financial-Times/kafka-client-go/kafka"
 	status "github.com/Financial-Times/service-status-go/httphandlers"
 	"github.com/gorilla/mux"
 	cli "github.com/jawher/mow.cli"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
 )
 
 const (
@@ -22,38 +26,39 @@ const (
 	consumerStartedEvent       = "consume_queue"
 	producerStartedEvent       = "produce_queue"
 	mapperEvent                = "Map"
+	kafkaRetryInterval         = 10 * time.Second
 )
 
-var (
-	messageConsumer  kafka.Consumer
-	messageProducer  kafka.Producer
-	taxonomyHandlers map[string]TaxonomyService
-	whitelist        *regexp.Regexp
-)
+// mappingOptions are the command line options shared by the service and the map subcommand
+type mappingOptions struct {
+	whitelistRegex        *string
+	taxonomyConfigFile    *string
+	validationPolicy      *string
+	ignoredTaxonomies     *[]string
+	outputFormat          *string
+	schemaCheck           *string
+	propagatedHeaders     *[]string
+	sourceHeaders         *bool
+	runtimeConfigFile     *string
+	runtimeConfigInterval *int
+}
 
 func init() {
 	logger.InitDefaultLogger(serviceName)
-	taxonomyHandlers = map[string]TaxonomyService{
-		"subjects":         SubjectService{HandledTaxonomy: "subjects"},
-		"sections":         SectionService{HandledTaxonomy: "sections"},
-		"topics":           TopicService{HandledTaxonomy: "topics"},
-		"locations":        LocationService{HandledTaxonomy: "gl"},
-		"genres":           GenreService{HandledTaxonomy: "genres"},
-		"specialReports":   SpecialReportService{HandledTaxonomy: "specialReports"},
-		"alphavilleSeries": AlphavilleSeriesService{HandledTaxonomy: "alphavilleSeriesClassification"},
-		"organisations":    OrganisationService{HandledTaxonomy: "ON"},
-		"people":           PeopleService{HandledTaxonomy: "PN"},
-		"authors":          AuthorService{HandledTaxonomy: "Authors"},
-		"brands":           BrandService{HandledTaxonomy: "Brands"},
-	}
 }
 
 func main() {
 	app := cli.App(serviceName, "A service to read V1 metadata publish event, filter it and output UPP-specific metadata to the destination queue.")
+	useZookeeper := app.Bool(cli.BoolOpt{
+		Name:   "useZookeeper",
+		Value:  false,
+		Desc:   "Use the deprecated Zookeeper-based queue consumer instead of joining the consumer group through the brokers",
+		EnvVar: "USE_ZOOKEEPER",
+	})
 	zookeeperAddress := app.String(cli.StringOpt{
 		Name:   "zookeeperAddress",
 		Value:  "localhost:2181",
-		Desc:   "Addresses used by the queue consumer to connect to the queue",
+		Desc:   "Addresses used by the Zookeeper-based queue consumer to connect to the queue",
 		EnvVar: "ZOOKEEPER_ADDRESS",
 	})
 	consumerGroup := app.String(cli.StringOpt{
@@ -66,9 +71,15 @@ func main() {
 		Desc:   "The topic to read the meassages from",
 		EnvVar: "CONSUMER_TOPIC",
 	})
+	consumerOffsetReset := app.String(cli.StringOpt{
+		Name:   "consumerOffsetReset",
+		Value:  latestOffsetReset,
+		Desc:   "Where the consumer starts reading a partition its group has no committed offset for: earliest or latest",
+		EnvVar: "CONSUMER_OFFSET_RESET",
+	})
 	brokerAddress := app.String(cli.StringOpt{
 		Name:   "brokerAddress",
-		Desc:   "Address used by the producer to connect to the queue",
+		Desc:   "Comma separated addresses of the Kafka brokers, used by the producer and the consumer to connect to the queue",
 		EnvVar: "BROKER_ADDRESS",
 	})
 	producerTopic := app.String(cli.StringOpt{
@@ -76,37 +87,304 @@ func main() {
 		Desc:   "The topic to write the concept annotation to",
 		EnvVar: "PRODUCER_TOPIC",
 	})
+	partitionKeyStrategy := app.String(cli.StringOpt{
+		Name:   "partitionKey",
+		Value:  contentUUIDKey,
+		Desc:   "What the concept annotations are keyed by, to choose their partition: uuid (the content UUID), transactionId or none",
+		EnvVar: "PARTITION_KEY",
+	})
+	produceMaxAttempts := app.Int(cli.IntOpt{
+		Name:   "produceAttempts",
+		Value:  5,
+		Desc:   "How many times to try writing the concept annotations of a message to the queue when it fails for a transient reason",
+		EnvVar: "PRODUCE_ATTEMPTS",
+	})
+	produceRetryBackoff := app.Int(cli.IntOpt{
+		Name:   "produceRetryBackoff",
+		Value:  100,
+		Desc:   "Milliseconds to wait before the first retry of writing to the queue, doubling after every retry",
+		EnvVar: "PRODUCE_RETRY_BACKOFF",
+	})
+	duplicateWindow := app.Int(cli.IntOpt{
+		Name:   "duplicateWindow",
+		Value:  0,
+		Desc:   "Seconds during which concept annotations identical to the ones last produced for the same content are not produced again. 0 disables the check",
+		EnvVar: "DUPLICATE_WINDOW",
+	})
+	duplicateCacheSize := app.Int(cli.IntOpt{
+		Name:   "duplicateCacheSize",
+		Value:  10000,
+		Desc:   "How many contents to remember the last produced concept annotations of, for the duplicate check",
+		EnvVar: "DUPLICATE_CACHE_SIZE",
+	})
+	deadLetterTopic := app.String(cli.StringOpt{
+		Name:   "deadLetterTopic",
+		Desc:   "The topic to write messages that could not be mapped to. Leave empty to disable the dead-letter queue",
+		EnvVar: "DEAD_LETTER_TOPIC",
+	})
 	whitelistRegex := app.String(cli.StringOpt{
 		Name:   "whitelistRegex",
 		Desc:   "The regex to use to filter messages based on Origin-System-Id.",
 		EnvVar: "WHITELIST_REGEX",
-		Value:  "http://cmdb\\.ft\\.com/systems/methode-web-pub",
+		Value:  defaultWhitelist,
+	})
+	taxonomyConfigFile := app.String(cli.StringOpt{
+		Name:   "taxonomyConfig",
+		Desc:   "Path to a JSON file with the taxonomy mapping rules. The built-in rules are used when empty",
+		EnvVar: "TAXONOMY_CONFIG",
+	})
+	validationPolicyName := app.String(cli.StringOpt{
+		Name:   "validationPolicy",
+		Value:  warnInvalidPolicy,
+		Desc:   "What to do with metadata that has validation problems: reject it, or warn and map it anyway",
+		EnvVar: "VALIDATION_POLICY",
+	})
+	concurrency := app.Int(cli.IntOpt{
+		Name:   "concurrency",
+		Value:  1,
+		Desc:   "How many messages to map in parallel, above 1 with the broker consumer only. Messages about the same content are always mapped in the order they were consumed",
+		EnvVar: "CONCURRENCY",
+	})
+	shutdownTimeout := app.Int(cli.IntOpt{
+		Name:   "shutdownTimeout",
+		Value:  20,
+		Desc:   "Seconds to wait on shutdown for the messages being mapped to be produced, before closing the producers and the HTTP server",
+		EnvVar: "SHUTDOWN_TIMEOUT",
+	})
+	ignoredTaxonomies := app.Strings(cli.StringsOpt{
+		Name:   "ignoredTaxonomies",
+		Value:  defaultIgnoredTaxonomies,
+		Desc:   "V1 taxonomies that are not mapped, but are not reported as unknown by the validation either",
+		EnvVar: "IGNORED_TAXONOMIES",
+	})
+	outputFormat := app.String(cli.StringOpt{
+		Name:   "outputFormat",
+		Value:  conceptAnnotationsFormat,
+		Desc:   "The schema the concept annotations are written in: conceptAnnotations, or pac for the flat annotations of the newer UPP writers",
+		EnvVar: "OUTPUT_FORMAT",
 	})
+	schemaCheckMode := app.String(cli.StringOpt{
+		Name:   "schemaCheck",
+		Value:  auditSchemaCheck,
+		Desc:   "How the concept annotations are checked against the JSON Schema of the output format before writing them: strict rejects them when they do not conform, audit logs it and writes them anyway, off skips the check",
+		EnvVar: "SCHEMA_CHECK",
+	})
+	propagatedHeaders := app.Strings(cli.StringsOpt{
+		Name:   "propagatedHeaders",
+		Desc:   "Headers of the metadata publish events written unchanged to the concept annotations, such as X-Publish-Reference, or * for all of them. The headers the mapper writes itself are never propagated",
+		EnvVar: "PROPAGATED_HEADERS",
+	})
+	sourceHeaders := app.Bool(cli.BoolOpt{
+		Name:   "sourceHeaders",
+		Value:  true,
+		Desc:   "Write the topic, partition and offset the metadata publish event was consumed from to the concept annotations, as the X-Source-Topic, X-Source-Partition and X-Source-Offset headers. Only the broker consumer knows them",
+		EnvVar: "SOURCE_HEADERS",
+	})
+	runtimeConfigFile := app.String(cli.StringOpt{
+		Name:   "runtimeConfig",
+		Desc:   "Path to a JSON file with mapping settings replacing the ones of the command line options: whitelist, taxonomies, ignoredTaxonomies, validationPolicy, outputFormat, schemaCheck, propagatedHeaders and sourceHeaders. It is applied again whenever its content changes",
+		EnvVar: "RUNTIME_CONFIG",
+	})
+	runtimeConfigInterval := app.Int(cli.IntOpt{
+		Name:   "runtimeConfigInterval",
+		Value:  10,
+		Desc:   "Seconds between checks of the runtime configuration file for changes",
+		EnvVar: "RUNTIME_CONFIG_INTERVAL",
+	})
+	tracingExporter := app.String(cli.StringOpt{
+		Name:   "tracingExporter",
+		Value:  noTracing,
+		Desc:   "Where the spans of the mapping stages are written: otlp to an OpenTelemetry collector, stdout for local use, or none to disable tracing",
+		EnvVar: "TRACING_EXPORTER",
+	})
+	otlpEndpoint := app.String(cli.StringOpt{
+		Name:   "otlpEndpoint",
+		Value:  defaultOTLPEndpoint,
+		Desc:   "Host and port of the OpenTelemetry collector the spans are written to over HTTP by the otlp tracing exporter",
+		EnvVar: "OTLP_ENDPOINT",
+	})
+	otlpInsecure := app.Bool(cli.BoolOpt{
+		Name:   "otlpInsecure",
+		Value:  false,
+		Desc:   "Write the spans to the OpenTelemetry collector over plain HTTP instead of HTTPS",
+		EnvVar: "OTLP_INSECURE",
+	})
+	adminToken := app.String(cli.StringOpt{
+		Name:   "adminToken",
+		Desc:   "Bearer token required to change the mapping settings with PUT /__config. Changing them is disabled when empty",
+		EnvVar: "ADMIN_TOKEN",
+	})
+
+	options := mappingOptions{
+		whitelistRegex:        whitelistRegex,
+		taxonomyConfigFile:    taxonomyConfigFile,
+		validationPolicy:      validationPolicyName,
+		ignoredTaxonomies:     ignoredTaxonomies,
+		outputFormat:          outputFormat,
+		schemaCheck:           schemaCheckMode,
+		propagatedHeaders:     propagatedHeaders,
+		sourceHeaders:         sourceHeaders,
+		runtimeConfigFile:     runtimeConfigFile,
+		runtimeConfigInterval: runtimeConfigInterval,
+	}
+
+	app.Command("map", "Map metadata publish events read from files instead of the queue, and write the concept annotations to a file", mapCommand(options))
 
 	app.Action = func() {
-		var err error
-		whitelist, err = regexp.Compile(*whitelistRegex)
+		store, baseSettings, watcher := configureMapping(options)
+		if watcher != nil {
+			go watcher.watch(nil)
+		}
+
+		tracerProvider, err := newTracerProvider(*tracingExporter, *otlpEndpoint, *otlpInsecure)
+		if err != nil {
+			logger.Fatalf(nil, err, "Please specify a valid tracing exporter")
+		}
+		if tracerProvider != nil {
+			otel.SetTracerProvider(tracerProvider)
+			logger.Infof(nil, "Writing the spans of the mapping stages with the %s exporter", *tracingExporter)
+		}
+
+		var messageConsumer kafka.Consumer
+		if *useZookeeper {
+			logger.Infof(map[string]interface{}{"event": consumerStartedEvent}, "Starting Zookeeper-based queue consumer: %v", *consumerTopic)
+			messageConsumer, _ = kafka.NewPerseverantConsumer(*zookeeperAddress, *consumerGroup, []string{*consumerTopic}, kafka.DefaultConsumerConfig(), time.Minute, nil)
+			if store.current().headers.sourceHeaders {
+				logger.Warnf(nil, "The Zookeeper-based consumer does not tell where the messages were consumed from, the source headers will not be written to the concept annotations")
+			}
+		} else {
+			logger.Infof(map[string]interface{}{"event": consumerStartedEvent}, "Starting queue consumer: %v", *consumerTopic)
+			messageConsumer, err = newBrokerConsumer(brokerConsumerConfig{
+				brokers:       splitBrokerAddresses(*brokerAddress),
+				group:         *consumerGroup,
+				topics:        []string{*consumerTopic},
+				offsetReset:   *consumerOffsetReset,
+				retryInterval: kafkaRetryInterval,
+			})
+			if err != nil {
+				logger.Fatalf(nil, err, "Please specify a valid consumer offset reset policy")
+			}
+		}
+
+		partitionKey, err := newPartitionKeyFunc(*partitionKeyStrategy)
 		if err != nil {
-			logger.Fatalf(nil, err, "Please specify a valid whitelist")
+			logger.Fatalf(nil, err, "Please specify a valid partition key strategy")
 		}
+		if *produceMaxAttempts < 1 {
+			logger.Fatalf(nil, fmt.Errorf("produce attempts %d is lower than 1", *produceMaxAttempts), "Please specify a valid number of produce attempts")
+		}
+		produceRetry := newProduceRetryPolicy(*produceMaxAttempts, time.Duration(*produceRetryBackoff)*time.Millisecond)
+		logger.Infof(map[string]interface{}{"event": producerStartedEvent}, "Starting queue producer: %s", *producerTopic)
+		messageProducer := newKeyedProducer(splitBrokerAddresses(*brokerAddress), *producerTopic, kafkaRetryInterval)
 
-		logger.Infof(map[string]interface{}{"event": consumerStartedEvent}, "Starting queue consumer: %v", *producerTopic)
-		messageConsumer, _ = kafka.NewPerseverantConsumer(*zookeeperAddress, *consumerGroup, []string{*consumerTopic}, kafka.DefaultConsumerConfig(), time.Minute, nil)
+		var duplicates *duplicateFilter
+		if *duplicateWindow > 0 {
+			if *duplicateCacheSize < 1 {
+				logger.Fatalf(nil, fmt.Errorf("duplicate cache size %d is lower than 1", *duplicateCacheSize), "Please specify a valid duplicate cache size")
+			}
+			duplicates = newDuplicateFilter(newLRUStore(*duplicateCacheSize), time.Duration(*duplicateWindow)*time.Second)
+		}
 
-		logger.Infof(map[string]interface{}{"event": consumerStartedEvent}, "Starting queue producer: %s", *consumerTopic)
-		messageProducer, _ = kafka.NewPerseverantProducer(*brokerAddress, *producerTopic, nil, 0, time.Minute)
+		producers := []kafka.Producer{messageProducer}
+		var deadLetterProducer kafka.Producer
+		if *deadLetterTopic != "" {
+			logger.Infof(map[string]interface{}{"event": producerStartedEvent}, "Starting dead-letter queue producer: %s", *deadLetterTopic)
+			deadLetterProducer, _ = kafka.NewPerseverantProducer(*brokerAddress, *deadLetterTopic, nil, 0, time.Minute)
+			producers = append(producers, deadLetterProducer)
+		}
 
-		go startKafkaConsumer(messageConsumer)
-		go startServer(messageConsumer, messageProducer)
+		annotationsMapper := NewMapper(MapperDependencies{
+			Producer:           messageProducer,
+			DeadLetterProducer: deadLetterProducer,
+			Config:             store,
+			PartitionKey:       partitionKey,
+			Retry:              produceRetry,
+			Duplicates:         duplicates,
+		})
+
+		if *concurrency < 1 {
+			logger.Fatalf(nil, fmt.Errorf("concurrency %d is lower than 1", *concurrency), "Please specify a valid concurrency")
+		}
+		tracker := &messageTracker{}
+		startConsumer := func() { startKafkaConsumer(messageConsumer, tracker.track(annotationsMapper.Map)) }
+		var pool *workerPool
+		if *concurrency > 1 {
+			// the Zookeeper consumer commits the offset of a message as soon as the handler returns, before the worker maps it
+			broker, ok := messageConsumer.(*brokerConsumer)
+			if !ok {
+				logger.Fatalf(nil, fmt.Errorf("concurrency %d needs the broker consumer", *concurrency), "Please set USE_ZOOKEEPER to false to map messages in parallel")
+			}
+			pool = newWorkerPool(*concurrency, annotationsMapper.Map)
+			startConsumer = func() { broker.StartListeningAsync(pool.trackedHandler(tracker)) }
+		}
+
+		server := newServer(messageConsumer, messageProducer, annotationsMapper, configAdmin{store: store, base: baseSettings, token: *adminToken})
+		go startConsumer()
+		go startServer(server)
 
 		waitForSignal()
-		messageConsumer.Shutdown()
+		shutdown(messageConsumer, producers, tracker, pool, server, time.Duration(*shutdownTimeout)*time.Second)
+		shutdownTracing(tracerProvider, time.Duration(*shutdownTimeout)*time.Second)
 	}
 
 	app.Run(os.Args)
 }
 
-func startServer(messageConsumer kafka.Consumer, messageProducer kafka.Producer) {
+// configureMapping applies the mapping configuration of the command line options, overlaid by the runtime configuration file when there is one.
+// It returns the store holding the mapping configuration, the settings of the command line options, which the runtime configuration
+// is overlaid on when it is reloaded, and the watcher of the runtime configuration file, if there is one.
+func configureMapping(options mappingOptions) (*configStore, mappingSettings, *configWatcher) {
+	rules := mapper.DefaultTaxonomyRules
+	if *options.taxonomyConfigFile != "" {
+		var err error
+		rules, err = mapper.LoadTaxonomyRules(*options.taxonomyConfigFile)
+		if err != nil {
+			logger.Fatalf(nil, err, "Please specify a valid taxonomy configuration")
+		}
+		logger.Infof(nil, "Loaded %d taxonomy mapping rules from %s", len(rules), *options.taxonomyConfigFile)
+	}
+
+	base := mappingSettings{
+		Whitelist:         *options.whitelistRegex,
+		Taxonomies:        rules,
+		IgnoredTaxonomies: *options.ignoredTaxonomies,
+		ValidationPolicy:  *options.validationPolicy,
+		OutputFormat:      *options.outputFormat,
+		SchemaCheck:       *options.schemaCheck,
+		PropagatedHeaders: *options.propagatedHeaders,
+		SourceHeaders:     *options.sourceHeaders,
+	}
+	config, err := newMappingConfig(base, startupConfigSource)
+	if err != nil {
+		logger.Fatalf(nil, err, "Please specify a valid mapping configuration")
+	}
+	store := &configStore{}
+	store.apply(config)
+
+	if *options.runtimeConfigFile == "" {
+		return store, base, nil
+	}
+	if *options.runtimeConfigInterval < 1 {
+		logger.Fatalf(nil, fmt.Errorf("runtime configuration interval %d is lower than 1", *options.runtimeConfigInterval), "Please specify a valid runtime configuration interval")
+	}
+	watcher := newConfigWatcher(store, base, *options.runtimeConfigFile, time.Duration(*options.runtimeConfigInterval)*time.Second)
+	if err = watcher.load(); err != nil {
+		logger.Fatalf(nil, err, "Please specify a valid runtime configuration")
+	}
+	return store, base, watcher
+}
+
+func splitBrokerAddresses(brokerAddress string) []string {
+	var brokers []string
+	for _, broker := range strings.Split(brokerAddress, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	return brokers
+}
+
+func newServer(messageConsumer kafka.Consumer, messageProducer kafka.Producer, annotationsMapper *Mapper, admin configAdmin) *http.Server {
 	hc := NewHealthCheck(messageConsumer, messageProducer)
 	router := mux.NewRouter()
 	router.HandleFunc("/__health", hc.Health())
@@ -115,9 +393,17 @@ func startServer(messageConsumer kafka.Consumer, messageProducer kafka.Producer)
 	router.HandleFunc(status.PingPathDW, status.PingHandler)
 	router.HandleFunc(status.BuildInfoPath, status.BuildInfoHandler)
 	router.HandleFunc(status.BuildInfoPathDW, status.BuildInfoHandler)
-	http.Handle("/", router)
-	err := http.ListenAndServe(":8080", nil)
-	if err != nil {
+	router.Handle("/metrics", promhttp.Handler())
+	router.HandleFunc("/__config", admin.getConfig).Methods("GET")
+	router.HandleFunc("/__config", admin.putConfig).Methods("PUT")
+	router.HandleFunc("/map", annotationsMapper.mapHandler).Methods("POST")
+	router.HandleFunc("/schema", annotationsMapper.schemaHandler).Methods("GET")
+	return &http.Server{Addr: ":8080", Handler: router}
+}
+
+func startServer(server *http.Server) {
+	err := server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
 		logger.Fatalf(nil, err, "Couldn't set up HTTP listener")
 	}
 }
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/go-logger"
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/Shopify/sarama"
+)
+
+// The offset reset policies of the broker consumer, used when its group has no committed offset for a partition
+const (
+	earliestOffsetReset = "earliest"
+	latestOffsetReset   = "latest"
+)
+
+const ftMessageVersionLine = "FTMSG/1.0"
+
+var errConsumerNotConnected = errors.New("consumer has not connected to Kafka yet")
+
+type brokerConsumerConfig struct {
+	brokers       []string
+	group         string
+	topics        []string
+	offsetReset   string
+	retryInterval time.Duration
+}
+
+// brokerConsumer consumes messages as a member of a consumer group coordinated by the Kafka brokers, without Zookeeper.
+// Like the perseverant consumer, it keeps trying to connect until it succeeds or it is shut down.
+type brokerConsumer struct {
+	config       brokerConsumerConfig
+	saramaConfig *sarama.Config
+	ctx          context.Context
+	cancel       context.CancelFunc
+	stopped      chan struct{}
+
+	mutex   sync.RWMutex
+	started bool
+	client  sarama.Client
+	group   sarama.ConsumerGroup
+}
+
+func newBrokerConsumer(config brokerConsumerConfig) (*brokerConsumer, error) {
+	saramaConfig, err := newConsumerSaramaConfig(config.offsetReset)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &brokerConsumer{config: config, saramaConfig: saramaConfig, ctx: ctx, cancel: cancel, stopped: make(chan struct{})}, nil
+}
+
+func newConsumerSaramaConfig(offsetReset string) (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	config.ClientID = serviceName
+	// consumer groups need at least Kafka 0.10.2
+	config.Version = sarama.V1_0_0_0
+	config.Consumer.Return.Errors = true
+
+	switch offsetReset {
+	case earliestOffsetReset:
+		config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	case latestOffsetReset:
+		config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	default:
+		return nil, fmt.Errorf("unknown offset reset policy %q, expected %q or %q", offsetReset, earliestOffsetReset, latestOffsetReset)
+	}
+	return config, nil
+}
+
+// asyncHandler handles a message, possibly in the background, and calls handled once the message is handled.
+// It returns errShuttingDown for the messages refused because of the shutdown, and never calls handled for them.
+type asyncHandler func(message kafka.FTMessage, handled func()) error
+
+// StartListening connects to the brokers, joins the consumer group and handles the messages of the claimed partitions until Shutdown is called
+func (c *brokerConsumer) StartListening(messageHandler func(message kafka.FTMessage) error) {
+	c.StartListeningAsync(handledOnReturn(messageHandler))
+}
+
+// handledOnReturn adapts a handler that handles the messages before returning
+func handledOnReturn(messageHandler func(message kafka.FTMessage) error) asyncHandler {
+	return func(message kafka.FTMessage, handled func()) error {
+		err := messageHandler(message)
+		if err != errShuttingDown {
+			handled()
+		}
+		return err
+	}
+}
+
+// StartListeningAsync works as StartListening, for a handler which hands the messages over to be handled in the background.
+// The offset of a message is only marked once it and every message before it on its partition are handled.
+func (c *brokerConsumer) StartListeningAsync(messageHandler asyncHandler) {
+	c.mutex.Lock()
+	if c.started || c.ctx.Err() != nil {
+		c.mutex.Unlock()
+		return
+	}
+	c.started = true
+	c.mutex.Unlock()
+	defer close(c.stopped)
+
+	if !c.connect() {
+		return
+	}
+	go c.logErrors()
+
+	handler := consumerGroupHandler{handle: messageHandler}
+	for c.ctx.Err() == nil {
+		// Consume returns at every rebalance of the group, and has to be called again to get the new claims
+		if err := c.group.Consume(c.ctx, c.config.topics, handler); err != nil {
+			logger.NewEntry("").WithError(err).Error("Error consuming messages from Kafka")
+			c.wait(c.config.retryInterval)
+		}
+	}
+}
+
+func (c *brokerConsumer) connect() bool {
+	for {
+		err := c.tryConnect()
+		if err == nil {
+			logger.Infof(map[string]interface{}{"event": consumerStartedEvent}, "Joined consumer group %s for topics %v", c.config.group, c.config.topics)
+			return true
+		}
+		logger.Errorf(nil, err, "Cannot connect the consumer to Kafka, retrying in %v", c.config.retryInterval)
+		if !c.wait(c.config.retryInterval) {
+			return false
+		}
+	}
+}
+
+func (c *brokerConsumer) tryConnect() error {
+	client, err := sarama.NewClient(c.config.brokers, c.saramaConfig)
+	if err != nil {
+		return err
+	}
+	group, err := sarama.NewConsumerGroupFromClient(c.config.group, client)
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	c.mutex.Lock()
+	c.client = client
+	c.group = group
+	c.mutex.Unlock()
+	return nil
+}
+
+func (c *brokerConsumer) logErrors() {
+	for err := range c.group.Errors() {
+		logger.NewEntry("").WithError(err).Error("Error reported by the Kafka consumer group")
+	}
+}
+
+// wait waits for the given duration, and returns false if the consumer was shut down in the meantime
+func (c *brokerConsumer) wait(d time.Duration) bool {
+	select {
+	case <-c.ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// Shutdown stops consuming, waits for the message being handled, and leaves the consumer group
+func (c *brokerConsumer) Shutdown() {
+	c.cancel()
+
+	c.mutex.RLock()
+	started := c.started
+	c.mutex.RUnlock()
+	if started {
+		<-c.stopped
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.group != nil {
+		if err := c.group.Close(); err != nil {
+			logger.NewEntry("").WithError(err).Error("Error leaving the consumer group")
+		}
+		c.group = nil
+	}
+	if c.client != nil {
+		if err := c.client.Close(); err != nil {
+			logger.NewEntry("").WithError(err).Error("Error closing the consumer connection to Kafka")
+		}
+		c.client = nil
+	}
+}
+
+// ConnectivityCheck checks that the brokers can be reached and know the consumed topics
+func (c *brokerConsumer) ConnectivityCheck() error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if c.client == nil {
+		return errConsumerNotConnected
+	}
+	return c.client.RefreshMetadata(c.config.topics...)
+}
+
+type consumerGroupHandler struct {
+	handle asyncHandler
+}
+
+func (h consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim hands the messages of a partition over to the handler in order. As with the Zookeeper consumer, messages that fail
+// are not retried: the mapper logs them and sends them to the dead-letter queue, and their offset is marked like any other.
+// Offsets are marked as the messages are handled, never past a message still being handled, and the messages refused because
+// of the shutdown are left unmarked, so that they are consumed again. It waits for the accepted messages before returning,
+// so that their offsets are committed with the session.
+func (h consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	marks := &offsetWatermark{session: session}
+	var accepted sync.WaitGroup
+	defer accepted.Wait()
+	for {
+		select {
+		case <-session.Context().Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			marks.add(msg)
+			accepted.Add(1)
+			err := h.handle(withSourceHeaders(parseFTMessage(string(msg.Value)), msg), func() {
+				marks.done(msg)
+				accepted.Done()
+			})
+			if err == errShuttingDown {
+				accepted.Done()
+				return nil
+			}
+		}
+	}
+}
+
+// offsetWatermark marks the offsets of a partition as its messages are handled, possibly out of order,
+// never marking the offset of a message until every message consumed before it is handled
+type offsetWatermark struct {
+	session sarama.ConsumerGroupSession
+	mutex   sync.Mutex
+	pending []*sarama.ConsumerMessage
+	handled map[int64]bool
+}
+
+// add records a message handed over to the handler, in the order of the partition
+func (w *offsetWatermark) add(msg *sarama.ConsumerMessage) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.pending = append(w.pending, msg)
+}
+
+// done records that a message is handled, and marks the last message handled along with every message before it
+func (w *offsetWatermark) done(msg *sarama.ConsumerMessage) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.handled == nil {
+		w.handled = map[int64]bool{}
+	}
+	w.handled[msg.Offset] = true
+
+	var last *sarama.ConsumerMessage
+	for len(w.pending) > 0 && w.handled[w.pending[0].Offset] {
+		last = w.pending[0]
+		delete(w.handled, last.Offset)
+		w.pending = w.pending[1:]
+	}
+	if last != nil {
+		w.session.MarkMessage(last, "")
+	}
+}
+
+// parseFTMessage reads a message in the FTMSG/1.0 format: a version line, a line per header, an empty line and the body
+func parseFTMessage(raw string) kafka.FTMessage {
+	headers := map[string]string{}
+	if !strings.HasPrefix(raw, ftMessageVersionLine) {
+		return kafka.FTMessage{Headers: headers, Body: raw}
+	}
+
+	rest := raw
+	first := true
+	for rest != "" {
+		var line string
+		if i := strings.Index(rest, "\n"); i >= 0 {
+			line, rest = rest[:i], rest[i+1:]
+		} else {
+			line, rest = rest, ""
+		}
+		line = strings.TrimSuffix(line, "\r")
+		if first {
+			first = false
+			continue
+		}
+		if line == "" {
+			break
+		}
+		if i := strings.Index(line, ":"); i > 0 {
+			headers[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+		}
+	}
+	return kafka.FTMessage{Headers: headers, Body: rest}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/Shopify/sarama"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFTMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected kafka.FTMessage
+	}{
+		{
+			"CRLF line endings",
+			"FTMSG/1.0\r\nX-Request-Id: tid_test\r\nOrigin-System-Id: http://cmdb.ft.com/systems/methode-web-pub\r\n\r\n{\"uuid\":\"1234\"}",
+			kafka.FTMessage{
+				Headers: map[string]string{"X-Request-Id": "tid_test", "Origin-System-Id": "http://cmdb.ft.com/systems/methode-web-pub"},
+				Body:    `{"uuid":"1234"}`,
+			},
+		},
+		{
+			"LF line endings and a multiline body",
+			"FTMSG/1.0\nMessage-Timestamp: 2019-01-01T10:00:00.000Z\n\nfirst line\n\nsecond line",
+			kafka.FTMessage{
+				Headers: map[string]string{"Message-Timestamp": "2019-01-01T10:00:00.000Z"},
+				Body:    "first line\n\nsecond line",
+			},
+		},
+		{
+			"No headers",
+			"FTMSG/1.0\r\n\r\nbody",
+			kafka.FTMessage{Headers: map[string]string{}, Body: "body"},
+		},
+		{
+			"Not an FT message",
+			`{"uuid":"1234"}`,
+			kafka.FTMessage{Headers: map[string]string{}, Body: `{"uuid":"1234"}`},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, parseFTMessage(test.raw), test.name)
+	}
+}
+
+func TestNewConsumerSaramaConfig(t *testing.T) {
+	config, err := newConsumerSaramaConfig(earliestOffsetReset)
+	require.NoError(t, err)
+	assert.Equal(t, sarama.OffsetOldest, config.Consumer.Offsets.Initial)
+
+	config, err = newConsumerSaramaConfig(latestOffsetReset)
+	require.NoError(t, err)
+	assert.Equal(t, sarama.OffsetNewest, config.Consumer.Offsets.Initial)
+
+	_, err = newConsumerSaramaConfig("smallest")
+	assert.EqualError(t, err, `unknown offset reset policy "smallest", expected "earliest" or "latest"`)
+}
+
+type mockConsumerGroupSession struct {
+	mutex  sync.Mutex
+	marked []*sarama.ConsumerMessage
+}
+
+func (s *mockConsumerGroupSession) Claims() map[string][]int32 { return nil }
+func (s *mockConsumerGroupSession) MemberID() string           { return "" }
+func (s *mockConsumerGroupSession) GenerationID() int32        { return 0 }
+func (s *mockConsumerGroupSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+}
+func (s *mockConsumerGroupSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+}
+func (s *mockConsumerGroupSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.marked = append(s.marked, msg)
+}
+
+func (s *mockConsumerGroupSession) markedOffsets() []int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var offsets []int64
+	for _, msg := range s.marked {
+		offsets = append(offsets, msg.Offset)
+	}
+	return offsets
+}
+func (s *mockConsumerGroupSession) Context() context.Context { return context.Background() }
+
+type mockConsumerGroupClaim struct {
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c mockConsumerGroupClaim) Topic() string                            { return "NativeCmsMetadataPublicationEvents" }
+func (c mockConsumerGroupClaim) Partition() int32                         { return 0 }
+func (c mockConsumerGroupClaim) InitialOffset() int64                     { return 0 }
+func (c mockConsumerGroupClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c mockConsumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func TestConsumeClaim__MarksEveryHandledMessage(t *testing.T) {
+	claim := mockConsumerGroupClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- &sarama.ConsumerMessage{Value: []byte("FTMSG/1.0\r\nX-Request-Id: tid_1\r\n\r\nfirst"), Topic: "NativeCmsMetadataPublicationEvents", Partition: 3, Offset: 1}
+	claim.messages <- &sarama.ConsumerMessage{Value: []byte("FTMSG/1.0\r\nX-Request-Id: tid_2\r\nX-Source-Offset: 42\r\n\r\nsecond"), Topic: "NativeCmsMetadataPublicationEvents", Partition: 3, Offset: 2}
+	close(claim.messages)
+	session := &mockConsumerGroupSession{}
+
+	var handled []kafka.FTMessage
+	handler := consumerGroupHandler{handle: handledOnReturn(func(message kafka.FTMessage) error {
+		handled = append(handled, message)
+		return errors.New("mapping failed")
+	})}
+
+	err := handler.ConsumeClaim(session, claim)
+
+	assert.NoError(t, err)
+	require.Len(t, handled, 2)
+	assert.Equal(t, "tid_1", handled[0].Headers["X-Request-Id"])
+	assert.Equal(t, "second", handled[1].Body)
+	assert.Equal(t, "NativeCmsMetadataPublicationEvents", handled[0].Headers[sourceTopicHeader])
+	assert.Equal(t, "3", handled[0].Headers[sourcePartitionHeader])
+	assert.Equal(t, "1", handled[0].Headers[sourceOffsetHeader])
+	assert.Equal(t, "2", handled[1].Headers[sourceOffsetHeader], "the consumed offset replaces the upstream one")
+	require.Len(t, session.marked, 2)
+	assert.Equal(t, int64(2), session.marked[1].Offset)
+}
+
+func TestOffsetWatermark__MarksOnlyOnceEveryEarlierMessageIsHandled(t *testing.T) {
+	session := &mockConsumerGroupSession{}
+	marks := &offsetWatermark{session: session}
+	msgs := []*sarama.ConsumerMessage{{Offset: 1}, {Offset: 2}, {Offset: 3}, {Offset: 4}}
+	for _, msg := range msgs {
+		marks.add(msg)
+	}
+
+	marks.done(msgs[1])
+	marks.done(msgs[2])
+	assert.Empty(t, session.markedOffsets(), "the first message is still being handled")
+
+	marks.done(msgs[0])
+	assert.Equal(t, []int64{3}, session.markedOffsets())
+
+	marks.done(msgs[3])
+	assert.Equal(t, []int64{3, 4}, session.markedOffsets())
+}
+
+func TestConsumeClaim__MarksTheMessagesHandledByTheWorkerPoolOnceMapped(t *testing.T) {
+	slowUUID := uuid.New()
+	release := make(chan struct{})
+	fastHandled := make(chan struct{})
+	pool := newWorkerPool(2, func(ctx context.Context, msg kafka.FTMessage) error {
+		if strings.Contains(msg.Body, slowUUID) {
+			<-release
+		} else {
+			close(fastHandled)
+		}
+		return nil
+	})
+	fastUUID := uuid.New()
+	for pool.workerIndex(kafka.FTMessage{Body: `{"uuid":"` + fastUUID + `"}`}) == pool.workerIndex(kafka.FTMessage{Body: `{"uuid":"` + slowUUID + `"}`}) {
+		fastUUID = uuid.New()
+	}
+	claim := mockConsumerGroupClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- &sarama.ConsumerMessage{Value: []byte(`{"uuid":"` + slowUUID + `"}`), Offset: 1}
+	claim.messages <- &sarama.ConsumerMessage{Value: []byte(`{"uuid":"` + fastUUID + `"}`), Offset: 2}
+	close(claim.messages)
+	session := &mockConsumerGroupSession{}
+	handler := consumerGroupHandler{handle: pool.trackedHandler(&messageTracker{})}
+
+	consumed := make(chan error)
+	go func() {
+		consumed <- handler.ConsumeClaim(session, claim)
+	}()
+	<-fastHandled
+	select {
+	case <-consumed:
+		t.Fatal("the claim was left before the accepted messages were handled")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Empty(t, session.markedOffsets(), "the first message is not mapped yet")
+
+	close(release)
+	assert.NoError(t, <-consumed)
+	assert.Equal(t, []int64{2}, session.markedOffsets())
+	pool.close()
+}
+
+func TestBrokerConsumer__ShutdownStopsConnecting(t *testing.T) {
+	consumer, err := newBrokerConsumer(brokerConsumerConfig{brokers: []string{"localhost:1"}, group: "annotations-mapper", topics: []string{"NativeCmsMetadataPublicationEvents"}, offsetReset: latestOffsetReset, retryInterval: time.Hour})
+	require.NoError(t, err)
+
+	stopped := make(chan struct{})
+	go func() {
+		consumer.StartListening(func(message kafka.FTMessage) error { return nil })
+		close(stopped)
+	}()
+
+	// give the consumer the time to fail connecting
+	time.Sleep(100 * time.Millisecond)
+	consumer.Shutdown()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the consumer did not stop listening")
+	}
+	assert.Equal(t, errConsumerNotConnected, consumer.ConnectivityCheck())
+}
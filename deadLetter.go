@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+
+	logger "github.com/Financial-Times/go-logger"
+	"github.com/Financial-Times/kafka-client-go/kafka"
+)
+
+const (
+	failureStageHeader     = "X-Failure-Stage"
+	failureReasonHeader    = "X-Failure-Reason"
+	failureTimestampHeader = "X-Failure-Timestamp"
+)
+
+// sendToDeadLetter forwards a message that could not be mapped to the dead-letter topic, keeping the
+// original headers and body and describing the failure in additional headers, so it can be reprocessed later.
+// It does nothing when the mapper has no dead-letter producer.
+func (m *Mapper) sendToDeadLetter(msg kafka.FTMessage, stage string, cause error) {
+	if m.deadLetter == nil {
+		return
+	}
+
+	headers := make(map[string]string, len(msg.Headers)+3)
+	for name, value := range msg.Headers {
+		headers[name] = value
+	}
+	headers[failureStageHeader] = stage
+	headers[failureReasonHeader] = headerValue(cause.Error())
+	headers[failureTimestampHeader] = m.now().UTC().Format(messageTimestampDateFormat)
+
+	tid := msg.Headers["X-Request-Id"]
+	if err := m.deadLetter.SendMessage(kafka.FTMessage{Headers: headers, Body: msg.Body}); err != nil {
+		logger.NewEntry(tid).WithError(err).Errorf("Error sending message that failed at stage %q to the dead-letter queue", stage)
+		return
+	}
+	logger.NewEntry(tid).Infof("Message that failed at stage %q was sent to the dead-letter queue", stage)
+}
+
+// headerValue replaces with spaces the characters that the FTMSG header values of kafka-client-go cannot hold,
+// so that the value is not cut off when it is read back, and a new line does not break the framing of the message
+func headerValue(value string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', strings.ContainsRune("_-:/.+;= ", r):
+			return r
+		}
+		return ' '
+	}, value))
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/Financial-Times/kafka-client-go/kafka"
+)
+
+// The actions of the filter rules
+const (
+	includeFilterAction = "include"
+	excludeFilterAction = "exclude"
+)
+
+// notIncludedRule is reported as the rule that skipped a message when there are include rules and none of them matched it
+const notIncludedRule = "notIncluded"
+
+// filterRule includes or excludes the messages matching its condition. Its name is logged with the messages it skips.
+type filterRule struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	filterCondition
+}
+
+// filterCondition matches a header or a top level field of the metadata publish event, either against a list of values or a regex,
+// or combines other conditions: a message matches all when it matches every one of them, and any when it matches at least one of them.
+// Missing headers and fields are matched as empty strings.
+type filterCondition struct {
+	Header  string            `json:"header,omitempty"`
+	Field   string            `json:"field,omitempty"`
+	Equals  []string          `json:"equals,omitempty"`
+	Matches string            `json:"matches,omitempty"`
+	All     []filterCondition `json:"all,omitempty"`
+	Any     []filterCondition `json:"any,omitempty"`
+}
+
+type filterMatcher func(msg *filteredMessage) bool
+
+type compiledFilterRule struct {
+	name  string
+	match filterMatcher
+}
+
+// messageFilter skips the messages matched by any exclude rule, and, when there are include rules, the messages not matched by any of them
+type messageFilter struct {
+	excludes []compiledFilterRule
+	includes []compiledFilterRule
+}
+
+func newMessageFilter(rules []filterRule) (*messageFilter, error) {
+	filter := &messageFilter{}
+	names := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("filter rule %d has no name", i)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("filter rule %q is defined more than once", rule.Name)
+		}
+		names[rule.Name] = true
+
+		match, err := compileFilterCondition(rule.filterCondition)
+		if err != nil {
+			return nil, fmt.Errorf("filter rule %q: %v", rule.Name, err)
+		}
+		switch rule.Action {
+		case includeFilterAction:
+			filter.includes = append(filter.includes, compiledFilterRule{name: rule.Name, match: match})
+		case excludeFilterAction:
+			filter.excludes = append(filter.excludes, compiledFilterRule{name: rule.Name, match: match})
+		default:
+			return nil, fmt.Errorf("filter rule %q has an unknown action %q, expected %q or %q", rule.Name, rule.Action, includeFilterAction, excludeFilterAction)
+		}
+	}
+	return filter, nil
+}
+
+func compileFilterCondition(c filterCondition) (filterMatcher, error) {
+	kinds := 0
+	for _, set := range []bool{c.Header != "", c.Field != "", c.All != nil, c.Any != nil} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return nil, fmt.Errorf("a condition needs exactly one of header, field, all or any")
+	}
+
+	if c.All != nil || c.Any != nil {
+		conditions := c.All
+		if c.Any != nil {
+			conditions = c.Any
+		}
+		if len(conditions) == 0 {
+			return nil, fmt.Errorf("a condition combines no conditions")
+		}
+		matchers := make([]filterMatcher, len(conditions))
+		for i, condition := range conditions {
+			match, err := compileFilterCondition(condition)
+			if err != nil {
+				return nil, err
+			}
+			matchers[i] = match
+		}
+		if c.All != nil {
+			return matchAll(matchers), nil
+		}
+		return matchAny(matchers), nil
+	}
+
+	value := func(msg *filteredMessage) string { return msg.headers[c.Header] }
+	if c.Field != "" {
+		value = func(msg *filteredMessage) string { return msg.field(c.Field) }
+	}
+
+	if (c.Equals != nil) == (c.Matches != "") {
+		return nil, fmt.Errorf("a header or field condition needs exactly one of equals or matches")
+	}
+	if c.Matches != "" {
+		pattern, err := regexp.Compile(c.Matches)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %v", c.Matches, err)
+		}
+		return func(msg *filteredMessage) bool { return pattern.MatchString(value(msg)) }, nil
+	}
+	values := make(map[string]bool, len(c.Equals))
+	for _, v := range c.Equals {
+		values[v] = true
+	}
+	return func(msg *filteredMessage) bool { return values[value(msg)] }, nil
+}
+
+func matchAll(matchers []filterMatcher) filterMatcher {
+	return func(msg *filteredMessage) bool {
+		for _, match := range matchers {
+			if !match(msg) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func matchAny(matchers []filterMatcher) filterMatcher {
+	return func(msg *filteredMessage) bool {
+		for _, match := range matchers {
+			if match(msg) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// skippedBy returns the name of the rule that skips the message, or an empty string when the message is mapped
+func (f *messageFilter) skippedBy(msg kafka.FTMessage) string {
+	filtered := &filteredMessage{headers: msg.Headers, body: msg.Body}
+	for _, rule := range f.excludes {
+		if rule.match(filtered) {
+			return rule.name
+		}
+	}
+	if len(f.includes) == 0 {
+		return ""
+	}
+	for _, rule := range f.includes {
+		if rule.match(filtered) {
+			return ""
+		}
+	}
+	return notIncludedRule
+}
+
+// filteredMessage parses the metadata publish event only when a condition matches one of its fields.
+// A body that cannot be parsed has no fields, and fails later when it is mapped.
+type filteredMessage struct {
+	headers map[string]string
+	body    string
+	parsed  bool
+	fields  map[string]interface{}
+}
+
+func (m *filteredMessage) field(name string) string {
+	if !m.parsed {
+		m.parsed = true
+		_ = json.Unmarshal([]byte(m.body), &m.fields)
+	}
+	value, _ := m.fields[name].(string)
+	return value
+}
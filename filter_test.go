@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	logger "github.com/Financial-Times/go-logger"
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFilterRules = `[
+	{"name": "quarantined", "action": "exclude", "field": "uuid", "equals": ["f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2"]},
+	{"name": "test-sources", "action": "exclude", "all": [
+		{"header": "Origin-System-Id", "matches": "methode-web-pub$"},
+		{"header": "X-Test-Marker", "matches": "."}
+	]},
+	{"name": "methode", "action": "include", "any": [
+		{"header": "Content-Type", "equals": ["application/json", ""]},
+		{"header": "Content-Type", "matches": "^application/vnd\\.ft-upp-metadata\\+json"}
+	]}
+]`
+
+func testMessageFilter(t *testing.T, rules string) *messageFilter {
+	var filterRules []filterRule
+	require.NoError(t, json.Unmarshal([]byte(rules), &filterRules))
+	filter, err := newMessageFilter(filterRules)
+	require.NoError(t, err)
+	return filter
+}
+
+func TestMessageFilter__SkippedBy(t *testing.T) {
+	filter := testMessageFilter(t, testFilterRules)
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		contentUUID  string
+		expectedRule string
+	}{
+		{"included", map[string]string{"Content-Type": "application/json"}, "0cef259d-030d-497d-b4ef-e8fa0ee6db6b", ""},
+		{"included without content type", map[string]string{}, "0cef259d-030d-497d-b4ef-e8fa0ee6db6b", ""},
+		{"quarantined", map[string]string{"Content-Type": "application/json"}, "f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2", "quarantined"},
+		{"test source", map[string]string{"Origin-System-Id": "http://cmdb.ft.com/systems/methode-web-pub", "X-Test-Marker": "true"}, "0cef259d-030d-497d-b4ef-e8fa0ee6db6b", "test-sources"},
+		{"not a test source", map[string]string{"Origin-System-Id": "http://cmdb.ft.com/systems/methode-web-pub", "X-Test-Marker": ""}, "0cef259d-030d-497d-b4ef-e8fa0ee6db6b", ""},
+		{"not included", map[string]string{"Content-Type": "application/xml"}, "0cef259d-030d-497d-b4ef-e8fa0ee6db6b", notIncludedRule},
+	}
+	for _, test := range tests {
+		msg := kafka.FTMessage{Headers: test.headers, Body: `{"uuid":"` + test.contentUUID + `","value":""}`}
+		assert.Equal(t, test.expectedRule, filter.skippedBy(msg), test.name)
+	}
+}
+
+func TestMessageFilter__InvalidBodyHasNoFields(t *testing.T) {
+	filter := testMessageFilter(t, `[{"name": "no-uuid", "action": "exclude", "field": "uuid", "equals": [""]}]`)
+
+	assert.Equal(t, "no-uuid", filter.skippedBy(kafka.FTMessage{Body: "not JSON"}))
+}
+
+func TestMessageFilter__NoRules(t *testing.T) {
+	filter, err := newMessageFilter(nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "", filter.skippedBy(kafka.FTMessage{Headers: map[string]string{}}))
+}
+
+func TestNewMessageFilter__InvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{"no name", `[{"action": "exclude", "header": "X-Test", "equals": ["true"]}]`},
+		{"duplicate name", `[{"name": "a", "action": "exclude", "header": "X-Test", "equals": ["true"]}, {"name": "a", "action": "include", "header": "X-Test", "equals": ["false"]}]`},
+		{"unknown action", `[{"name": "a", "action": "drop", "header": "X-Test", "equals": ["true"]}]`},
+		{"header and field", `[{"name": "a", "action": "exclude", "header": "X-Test", "field": "uuid", "equals": ["true"]}]`},
+		{"nothing to match", `[{"name": "a", "action": "exclude", "equals": ["true"]}]`},
+		{"equals and matches", `[{"name": "a", "action": "exclude", "header": "X-Test", "equals": ["true"], "matches": "true"}]`},
+		{"invalid regex", `[{"name": "a", "action": "exclude", "header": "X-Test", "matches": "("}]`},
+		{"empty all", `[{"name": "a", "action": "exclude", "all": []}]`},
+		{"invalid nested condition", `[{"name": "a", "action": "exclude", "any": [{"header": "X-Test"}]}]`},
+	}
+	for _, test := range tests {
+		var rules []filterRule
+		require.NoError(t, json.Unmarshal([]byte(test.rules), &rules), test.name)
+		_, err := newMessageFilter(rules)
+		assert.Error(t, err, test.name)
+	}
+}
+
+func TestHandleMessage__FilteredOut(t *testing.T) {
+	filter := testMessageFilter(t, testFilterRules)
+	producer := &mockProducer{}
+	mapper := newTestMapper(producer)
+	setTestConfig(mapper, func(config *mappingConfig) { config.filter = filter })
+
+	hook := logger.NewTestHook("")
+	require.NoError(t, mapper.Map(context.Background(), publishEventMessage("f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2", "tid_test")))
+
+	assert.Empty(t, producer.messages)
+	entry := hook.LastEntry()
+	assert.Equal(t, "Skipping message with Origin-System-Id \"http://cmdb.ft.com/systems/methode-web-pub\" filtered out by rule \"quarantined\".", entry.Message)
+	assert.Equal(t, "quarantined", entry.Data["filterRule"])
+
+	require.NoError(t, mapper.Map(context.Background(), publishEventMessage("0cef259d-030d-497d-b4ef-e8fa0ee6db6b", "tid_test")))
+	assert.Len(t, producer.messages, 1)
+}
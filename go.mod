@@ -9,20 +9,23 @@ require (
 	github.com/Financial-Times/kafka-client-go v0.0.0-20181214120216-c3a1941e42a4
 	github.com/Financial-Times/service-status-go v0.0.0-20160323111542-3f5199736a3d
 	github.com/Financial-Times/uuid-utils-go v0.0.0-20170516110427-e22658edd0f1
-	github.com/Shopify/sarama v1.23.1 // indirect
-	github.com/google/uuid v1.1.0 // indirect
+	github.com/Shopify/sarama v1.23.1
 	github.com/gorilla/context v1.1.1 // indirect
 	github.com/gorilla/mux v1.4.1-0.20170524010104-043ee6597c29
 	github.com/hashicorp/go-version v0.0.0-20170202080759-03c5bf6be031 // indirect
 	github.com/jawher/mow.cli v0.0.0-20170430135212-8327d12beb75
 	github.com/pborman/uuid v0.0.0-20180906182336-adf5a7427709
-	github.com/pkg/errors v0.8.0
+	github.com/pkg/errors v0.8.1
+	github.com/prometheus/client_golang v1.7.1
 	github.com/samuel/go-zookeeper v0.0.0-20161028232340-1d7be4effb13 // indirect
-	github.com/sirupsen/logrus v1.2.0 // indirect
-	github.com/stretchr/testify v1.3.0
+	github.com/stretchr/testify v1.8.0
 	github.com/twinj/uuid v0.1.0
-	github.com/willf/bitset v1.1.2 // indirect
 	github.com/wvanbergen/kazoo-go v0.0.0-20160930072434-968957352185 // indirect
-	golang.org/x/time v0.0.0-20181108054448-85acf8d2951c // indirect
+	github.com/xeipuuv/gojsonschema v1.2.0
+	go.opentelemetry.io/otel v1.11.1
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.11.1
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.11.1
+	go.opentelemetry.io/otel/sdk v1.11.1
+	go.opentelemetry.io/otel/trace v1.11.1
 	gopkg.in/jcmturner/goidentity.v3 v3.0.0 // indirect
 )
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/Shopify/sarama"
+)
+
+// The headers the broker consumer adds to the messages, telling where they were consumed from
+const (
+	sourceTopicHeader     = "X-Source-Topic"
+	sourcePartitionHeader = "X-Source-Partition"
+	sourceOffsetHeader    = "X-Source-Offset"
+)
+
+// allHeaders propagates every header of the metadata publish event that the mapper does not write itself
+const allHeaders = "*"
+
+// mapperHeaders are written by the mapper to the concept annotations, or to the dead-letter messages, and are never propagated
+// from the metadata publish event. The trace context headers are the ones of the produce span.
+var mapperHeaders = map[string]bool{
+	"message-id":                 true,
+	"message-type":               true,
+	"content-type":               true,
+	"x-request-id":               true,
+	"origin-system-id":           true,
+	"message-timestamp":          true,
+	"x-source-message-timestamp": true,
+	"traceparent":                true,
+	"tracestate":                 true,
+	"baggage":                    true,
+	"x-failure-stage":            true,
+	"x-failure-reason":           true,
+	"x-failure-timestamp":        true,
+}
+
+var sourceHeaderNames = map[string]bool{
+	strings.ToLower(sourceTopicHeader):     true,
+	strings.ToLower(sourcePartitionHeader): true,
+	strings.ToLower(sourceOffsetHeader):    true,
+}
+
+// headerPolicy decides which headers of the metadata publish event are written unchanged to the concept annotations,
+// on top of the ones the mapper writes itself. Header names are matched regardless of case.
+type headerPolicy struct {
+	all           bool
+	propagated    map[string]bool
+	sourceHeaders bool
+}
+
+func newHeaderPolicy(propagated []string, propagateSource bool) (headerPolicy, error) {
+	policy := headerPolicy{propagated: make(map[string]bool, len(propagated)), sourceHeaders: propagateSource}
+	for i, name := range propagated {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		switch {
+		case name == "":
+			return headerPolicy{}, fmt.Errorf("propagated header %d has no name", i)
+		case name == allHeaders:
+			policy.all = true
+		case mapperHeaders[key]:
+			return headerPolicy{}, fmt.Errorf("header %q is written by the mapper and cannot be propagated", name)
+		case sourceHeaderNames[key]:
+			return headerPolicy{}, fmt.Errorf("header %q is a source header, propagated by the sourceHeaders setting", name)
+		default:
+			policy.propagated[key] = true
+		}
+	}
+	return policy, nil
+}
+
+// propagate copies the headers of the metadata publish event allowed by the policy to the headers of the concept annotations
+func (p headerPolicy) propagate(publishEventHeaders map[string]string, headers map[string]string) {
+	for name, value := range publishEventHeaders {
+		key := strings.ToLower(name)
+		switch {
+		case sourceHeaderNames[key]:
+			if p.sourceHeaders {
+				headers[name] = value
+			}
+		case mapperHeaders[key]:
+		case p.all || p.propagated[key]:
+			headers[name] = value
+		}
+	}
+}
+
+// withSourceHeaders adds the topic, partition and offset the message was consumed from to its headers, replacing any upstream value
+func withSourceHeaders(message kafka.FTMessage, consumed *sarama.ConsumerMessage) kafka.FTMessage {
+	message.Headers[sourceTopicHeader] = consumed.Topic
+	message.Headers[sourcePartitionHeader] = strconv.Itoa(int(consumed.Partition))
+	message.Headers[sourceOffsetHeader] = strconv.FormatInt(consumed.Offset, 10)
+	return message
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publishEventHeaders() map[string]string {
+	return map[string]string{
+		"Message-Id":          "fd0b5b0c-5ab4-4b0b-a5fd-1c6b0e26e1a5",
+		"Message-Type":        "cms-content-published",
+		"Content-Type":        "application/json",
+		"X-Request-Id":        "tid_test",
+		"Origin-System-Id":    "http://cmdb.ft.com/systems/methode-web-pub",
+		"Message-Timestamp":   "2020-06-01T12:00:00.000Z",
+		"X-Publish-Reference": "tid_publish",
+		"X-Native-Hash":       "5a4d1bb4f5b2",
+		"X-Failure-Stage":     produceStage,
+		"traceparent":         testTraceparent,
+		sourceTopicHeader:     "NativeCmsMetadataPublicationEvents",
+		sourcePartitionHeader: "3",
+		sourceOffsetHeader:    "42",
+	}
+}
+
+func TestNewHeaderPolicy(t *testing.T) {
+	_, err := newHeaderPolicy([]string{"X-Publish-Reference", " x-native-hash ", allHeaders}, true)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		headers []string
+	}{
+		{"empty name", []string{""}},
+		{"mapper header", []string{"Message-Id"}},
+		{"mapper header in another case", []string{"x-request-id"}},
+		{"trace context header", []string{"traceparent"}},
+		{"source message timestamp", []string{sourceMessageTimestampHeader}},
+		{"source header", []string{sourceOffsetHeader}},
+	}
+	for _, test := range tests {
+		_, err := newHeaderPolicy(test.headers, true)
+		assert.Error(t, err, test.name)
+	}
+}
+
+func TestBuildConceptAnnotationsHeader__PropagatedHeaders(t *testing.T) {
+	timestamp := time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		propagated    []string
+		sourceHeaders bool
+		expected      map[string]string
+	}{
+		{
+			name:     "none",
+			expected: map[string]string{},
+		},
+		{
+			name:          "source headers",
+			sourceHeaders: true,
+			expected: map[string]string{
+				sourceTopicHeader:     "NativeCmsMetadataPublicationEvents",
+				sourcePartitionHeader: "3",
+				sourceOffsetHeader:    "42",
+			},
+		},
+		{
+			name:       "allowlist",
+			propagated: []string{"x-publish-reference", "X-Content-Revision"},
+			expected:   map[string]string{"X-Publish-Reference": "tid_publish"},
+		},
+		{
+			name:       "pass-through",
+			propagated: []string{allHeaders},
+			expected:   map[string]string{"X-Publish-Reference": "tid_publish", "X-Native-Hash": "5a4d1bb4f5b2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := newHeaderPolicy(test.propagated, test.sourceHeaders)
+			require.NoError(t, err)
+
+			headers := buildConceptAnnotationsHeader(publishEventHeaders(), policy, "e2c7f8a2-92c0-4a1c-9a5d-0d5e2b7c8f01", timestamp)
+
+			expected := map[string]string{
+				"Message-Id":                 "e2c7f8a2-92c0-4a1c-9a5d-0d5e2b7c8f01",
+				"Message-Type":               "concept-annotation",
+				"Content-Type":               conceptAnnotationsContentType("application/json"),
+				"X-Request-Id":               "tid_test",
+				"Origin-System-Id":           "http://cmdb.ft.com/systems/methode-web-pub",
+				"Message-Timestamp":          "2020-06-01T12:30:00.000Z",
+				"X-Source-Message-Timestamp": "2020-06-01T12:00:00.000Z",
+			}
+			for name, value := range test.expected {
+				expected[name] = value
+			}
+			assert.Equal(t, expected, headers)
+		})
+	}
+}
+
+func TestMap__PropagatesTheConfiguredHeaders(t *testing.T) {
+	producer := &mockProducer{}
+	m := newTestMapper(producer)
+	setTestConfig(m, func(config *mappingConfig) {
+		policy, err := newHeaderPolicy([]string{"X-Publish-Reference"}, true)
+		require.NoError(t, err)
+		config.headers = policy
+	})
+	msg := publishEventMessage(uuid.New(), "tid_test")
+	msg.Headers["X-Publish-Reference"] = "tid_publish"
+	msg.Headers["X-Native-Hash"] = "5a4d1bb4f5b2"
+	msg.Headers[sourceOffsetHeader] = "42"
+
+	require.NoError(t, m.Map(context.Background(), msg))
+
+	require.Len(t, producer.messages, 1)
+	headers := producer.messages[0].Headers
+	assert.Equal(t, "tid_publish", headers["X-Publish-Reference"])
+	assert.Equal(t, "42", headers[sourceOffsetHeader])
+	assert.NotContains(t, headers, "X-Native-Hash")
+	assert.Equal(t, "concept-annotation", headers["Message-Type"])
+}
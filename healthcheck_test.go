@@ -144,3 +144,13 @@ func TestGTG__BrokenProducer(t *testing.T) {
 	assert.False(t, status.GoodToGo)
 	assert.Equal(t, "Error connecting to the queue", status.Message)
 }
+
+func TestGTG__BrokerConsumerNotConnected(t *testing.T) {
+	consumer, err := newBrokerConsumer(brokerConsumerConfig{brokers: []string{"localhost:9092"}, group: "annotations-mapper", topics: []string{"NativeCmsMetadataPublicationEvents"}, offsetReset: latestOffsetReset})
+	require.NoError(t, err)
+	hc := NewHealthCheck(consumer, mockKafkaConnection{err: nil})
+
+	status := hc.GTG()
+	assert.False(t, status.GoodToGo)
+	assert.Equal(t, errConsumerNotConnected.Error(), status.Message)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// forceRepublishHeader makes the concept annotations of a message be produced even when they are unchanged
+const forceRepublishHeader = "X-Force-Republish"
+
+// idempotencyStore remembers the hash of the concept annotations last produced for each content UUID.
+// It is an interface so that the in-memory store can be replaced by a store shared between instances.
+type idempotencyStore interface {
+	get(contentUUID string) (producedAnnotations, bool)
+	put(contentUUID string, produced producedAnnotations)
+}
+
+type producedAnnotations struct {
+	hash       string
+	producedAt time.Time
+}
+
+// duplicateFilter tells when the concept annotations of a content are the same as the ones produced within the window
+type duplicateFilter struct {
+	store  idempotencyStore
+	window time.Duration
+	now    func() time.Time
+}
+
+func newDuplicateFilter(store idempotencyStore, window time.Duration) *duplicateFilter {
+	return &duplicateFilter{store: store, window: window, now: time.Now}
+}
+
+// isDuplicate returns true if the same concept annotations were produced for the content within the window
+func (f *duplicateFilter) isDuplicate(contentUUID string, hash string) bool {
+	produced, found := f.store.get(contentUUID)
+	return found && produced.hash == hash && f.now().Sub(produced.producedAt) < f.window
+}
+
+// remember records the concept annotations produced for the content
+func (f *duplicateFilter) remember(contentUUID string, hash string) {
+	f.store.put(contentUUID, producedAnnotations{hash: hash, producedAt: f.now()})
+}
+
+func hashConceptAnnotations(marshalledAnnotations []byte) string {
+	sum := sha256.Sum256(marshalledAnnotations)
+	return hex.EncodeToString(sum[:])
+}
+
+func isForcedRepublish(headers map[string]string) bool {
+	return strings.EqualFold(headers[forceRepublishHeader], "true")
+}
+
+// lruStore is an in-memory idempotencyStore that forgets the least recently used contents beyond its capacity
+type lruStore struct {
+	mutex    sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type lruEntry struct {
+	contentUUID string
+	produced    producedAnnotations
+}
+
+func newLRUStore(capacity int) *lruStore {
+	return &lruStore{capacity: capacity, order: list.New(), entries: make(map[string]*list.Element, capacity)}
+}
+
+func (s *lruStore) get(contentUUID string) (producedAnnotations, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	element, found := s.entries[contentUUID]
+	if !found {
+		return producedAnnotations{}, false
+	}
+	s.order.MoveToFront(element)
+	return element.Value.(*lruEntry).produced, true
+}
+
+func (s *lruStore) put(contentUUID string, produced producedAnnotations) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if element, found := s.entries[contentUUID]; found {
+		element.Value.(*lruEntry).produced = produced
+		s.order.MoveToFront(element)
+		return
+	}
+
+	s.entries[contentUUID] = s.order.PushFront(&lruEntry{contentUUID: contentUUID, produced: produced})
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*lruEntry).contentUUID)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUStore__ForgetsTheLeastRecentlyUsed(t *testing.T) {
+	store := newLRUStore(2)
+	store.put("uuid-1", producedAnnotations{hash: "hash-1"})
+	store.put("uuid-2", producedAnnotations{hash: "hash-2"})
+	_, found := store.get("uuid-1")
+	require.True(t, found)
+
+	store.put("uuid-3", producedAnnotations{hash: "hash-3"})
+
+	_, found = store.get("uuid-2")
+	assert.False(t, found, "uuid-2 is the least recently used")
+	produced, found := store.get("uuid-1")
+	assert.True(t, found)
+	assert.Equal(t, "hash-1", produced.hash)
+	_, found = store.get("uuid-3")
+	assert.True(t, found)
+}
+
+func TestLRUStore__Replaces(t *testing.T) {
+	store := newLRUStore(2)
+	store.put("uuid-1", producedAnnotations{hash: "hash-1"})
+	store.put("uuid-1", producedAnnotations{hash: "hash-2"})
+
+	produced, found := store.get("uuid-1")
+	assert.True(t, found)
+	assert.Equal(t, "hash-2", produced.hash)
+	assert.Equal(t, 1, store.order.Len())
+}
+
+func TestDuplicateFilter(t *testing.T) {
+	now := time.Date(2019, 1, 1, 10, 0, 0, 0, time.UTC)
+	filter := newDuplicateFilter(newLRUStore(10), time.Minute)
+	filter.now = func() time.Time { return now }
+
+	assert.False(t, filter.isDuplicate("uuid-1", "hash-1"))
+	filter.remember("uuid-1", "hash-1")
+
+	assert.True(t, filter.isDuplicate("uuid-1", "hash-1"))
+	assert.False(t, filter.isDuplicate("uuid-1", "hash-2"), "the annotations changed")
+	assert.False(t, filter.isDuplicate("uuid-2", "hash-1"), "another content")
+
+	now = now.Add(time.Minute)
+	assert.False(t, filter.isDuplicate("uuid-1", "hash-1"), "the window is over")
+}
+
+func TestHandleMessage__SkipsUnchangedAnnotations(t *testing.T) {
+	producer := &mockProducer{}
+	mapper := newTestMapper(producer)
+	mapper.duplicates = newDuplicateFilter(newLRUStore(10), time.Hour)
+	unchanged := testutil.ToFloat64(messagesUnchanged)
+
+	contentUUID := uuid.New()
+	require.NoError(t, mapper.Map(context.Background(), publishEventMessage(contentUUID, "tid_1")))
+	require.NoError(t, mapper.Map(context.Background(), publishEventMessage(contentUUID, "tid_2")))
+	assert.Len(t, producer.messages, 1, "the republish is unchanged")
+	assert.Equal(t, unchanged+1, testutil.ToFloat64(messagesUnchanged))
+
+	forced := publishEventMessage(contentUUID, "tid_3")
+	forced.Headers[forceRepublishHeader] = "true"
+	require.NoError(t, mapper.Map(context.Background(), forced))
+	assert.Len(t, producer.messages, 2, "the republish is forced")
+
+	require.NoError(t, mapper.Map(context.Background(), publishEventMessage(uuid.New(), "tid_4")))
+	assert.Len(t, producer.messages, 3, "another content")
+}
+
+func TestHandleMessage__FailedProduceIsNotRemembered(t *testing.T) {
+	var sleeps []time.Duration
+	producer := &mockProducer{err: errProducerNotConnected}
+	mapper := newTestMapper(producer)
+	mapper.retry = testRetryPolicy(1, &sleeps)
+	mapper.duplicates = newDuplicateFilter(newLRUStore(10), time.Hour)
+
+	contentUUID := uuid.New()
+	assert.Error(t, mapper.Map(context.Background(), publishEventMessage(contentUUID, "tid_1")))
+	producer.err = nil
+	require.NoError(t, mapper.Map(context.Background(), publishEventMessage(contentUUID, "tid_2")))
+	assert.Len(t, producer.messages, 2)
+}
@@ -1,7 +1,8 @@
 package main
 
 import (
-	"regexp"
+	"context"
+	"encoding/base64"
 	"testing"
 
 	"github.com/Financial-Times/go-logger"
@@ -13,14 +14,12 @@ import (
 )
 
 func TestHandleMessage__UnsupportedSystemCode(t *testing.T) {
-	whitelist = regexp.MustCompile("http://cmdb\\.ft\\.com/systems/methode-web-pub")
-
 	msg := kafka.FTMessage{}
 	msg.Headers = make(map[string]string)
 	msg.Headers["Origin-System-Id"] = "http://cmdb.ft.com/systems/pac"
 
 	hook := logger.NewTestHook("")
-	err := handleMessage(msg)
+	err := newTestMapper(nil).Map(context.Background(), msg)
 	assert.NoError(t, err)
 
 	logLine := hook.LastEntry().Message
@@ -28,8 +27,6 @@ func TestHandleMessage__UnsupportedSystemCode(t *testing.T) {
 }
 
 func TestHandleMessage__InvalidJSONBody(t *testing.T) {
-	whitelist = regexp.MustCompile("http://cmdb\\.ft\\.com/systems/methode-web-pub")
-
 	msg := kafka.FTMessage{}
 	msg.Headers = make(map[string]string)
 	msg.Headers["Origin-System-Id"] = "http://cmdb.ft.com/systems/methode-web-pub"
@@ -37,7 +34,7 @@ func TestHandleMessage__InvalidJSONBody(t *testing.T) {
 
 	hook := logger.NewTestHook("")
 
-	err := handleMessage(msg)
+	err := newTestMapper(nil).Map(context.Background(), msg)
 	assert.Error(t, err) // should fail parsing json
 
 	logLine := hook.LastEntry()
@@ -58,7 +55,7 @@ func TestHandleMessage__InvalidBase64Value(t *testing.T) {
 
 	hook := logger.NewTestHook("")
 
-	err := handleMessage(msg)
+	err := newTestMapper(nil).Map(context.Background(), msg)
 	assert.Error(t, err) // should fail parsing json
 
 	logLine := hook.LastEntry()
@@ -78,7 +75,7 @@ func TestHandleMessage__InvalidXML(t *testing.T) {
 
 	hook := logger.NewTestHook("")
 
-	err := handleMessage(msg)
+	err := newTestMapper(nil).Map(context.Background(), msg)
 	assert.Error(t, err) // should fail parsing json
 
 	logLine := hook.LastEntry()
@@ -87,3 +84,120 @@ func TestHandleMessage__InvalidXML(t *testing.T) {
 	require.Equal(t, "false", logLine.Data["isValid"].(string))
 	require.Equal(t, testUUID, logLine.Data["uuid"].(string))
 }
+
+type mockProducer struct {
+	messages []kafka.FTMessage
+	err      error
+}
+
+func (p *mockProducer) SendMessage(message kafka.FTMessage) error {
+	p.messages = append(p.messages, message)
+	return p.err
+}
+
+func (p *mockProducer) ConnectivityCheck() error {
+	return p.err
+}
+
+func (p *mockProducer) Shutdown() {}
+
+func TestHandleMessage__FailuresAreSentToDeadLetterQueue(t *testing.T) {
+	testUUID := uuid.New()
+	tests := []struct {
+		name          string
+		body          string
+		expectedStage string
+	}{
+		{"Invalid JSON body", ``, jsonStage},
+		{"Invalid base64 value", `{"uuid":"` + testUUID + `","value":"I AM NOT BASE64!"}`, base64Stage},
+		{"Invalid metadata XML", `{"uuid":"` + testUUID + `","value":"eyJtc2ciOiJOb3QgWE1MIn0="}`, xmlStage},
+	}
+
+	for _, test := range tests {
+		dlq := &mockProducer{}
+		mapper := newTestMapper(nil)
+		mapper.deadLetter = dlq
+
+		msg := kafka.FTMessage{Body: test.body}
+		msg.Headers = map[string]string{
+			"Origin-System-Id": "http://cmdb.ft.com/systems/methode-web-pub",
+			"X-Request-Id":     "tid_test",
+		}
+
+		err := mapper.Map(context.Background(), msg)
+		assert.Error(t, err, test.name)
+
+		require.Len(t, dlq.messages, 1, test.name)
+		deadLetter := dlq.messages[0]
+		assert.Equal(t, test.body, deadLetter.Body, test.name)
+		assert.Equal(t, "tid_test", deadLetter.Headers["X-Request-Id"], test.name)
+		assert.Equal(t, "http://cmdb.ft.com/systems/methode-web-pub", deadLetter.Headers["Origin-System-Id"], test.name)
+		assert.Equal(t, test.expectedStage, deadLetter.Headers[failureStageHeader], test.name)
+		assert.Equal(t, headerValue(err.Error()), deadLetter.Headers[failureReasonHeader], test.name)
+		assert.NotEmpty(t, deadLetter.Headers[failureTimestampHeader], test.name)
+	}
+}
+
+func TestHeaderValue(t *testing.T) {
+	value := headerValue("invalid character 'I' looking for \"value\" (at line 1),\nX-Request-Id: tid_injected")
+
+	assert.Equal(t, "invalid character  I  looking for  value   at line 1   X-Request-Id: tid_injected", value)
+	assert.Regexp(t, `^[\w\-:/.+;= ]*$`, value)
+	assert.Equal(t, "parse error", headerValue(" parse error\r\n"))
+}
+
+const invalidMetadataXML = `<?xml version="1.0" encoding="UTF-8"?><ContentRef><tags><tag><term id="" taxonomy="Subjects"><canonicalName>Economic News</canonicalName></term><score confidence="90" relevance="90"/></tag><tag><term id="NjM=-U3ViamVjdHM=" taxonomy="Subjects"><canonicalName>Economic News</canonicalName></term><score confidence="90" relevance="90"/></tag></tags></ContentRef>`
+
+func TestHandleMessage__ValidationProblemsAreRejected(t *testing.T) {
+	producer := &mockProducer{}
+	dlq := &mockProducer{}
+	mapper := newTestMapper(producer)
+	mapper.deadLetter = dlq
+	setTestConfig(mapper, func(config *mappingConfig) { config.validationPolicy = rejectInvalidPolicy })
+
+	testUUID := uuid.New()
+	msg := kafka.FTMessage{Body: `{"uuid":"` + testUUID + `","value":"` + base64.StdEncoding.EncodeToString([]byte(invalidMetadataXML)) + `"}`}
+	msg.Headers = map[string]string{"Origin-System-Id": "http://cmdb.ft.com/systems/methode-web-pub", "X-Request-Id": "tid_test"}
+
+	hook := logger.NewTestHook("")
+	err := mapper.Map(context.Background(), msg)
+
+	assert.IsType(t, validationError{}, err)
+	assert.Empty(t, producer.messages)
+	require.Len(t, dlq.messages, 1)
+	assert.Equal(t, validationStage, dlq.messages[0].Headers[failureStageHeader])
+
+	logLine := hook.LastEntry()
+	assert.Equal(t, "Message is not valid due to validation problems.", logLine.Message)
+	assert.Equal(t, "false", logLine.Data["isValid"].(string))
+	assert.Equal(t, testUUID, logLine.Data["uuid"].(string))
+}
+
+func TestHandleMessage__ValidationProblemsAreMappedWithWarnings(t *testing.T) {
+	producer := &mockProducer{}
+	mapper := newTestMapper(producer)
+
+	testUUID := uuid.New()
+	msg := kafka.FTMessage{Body: `{"uuid":"` + testUUID + `","value":"` + base64.StdEncoding.EncodeToString([]byte(invalidMetadataXML)) + `"}`}
+	msg.Headers = map[string]string{"Origin-System-Id": "http://cmdb.ft.com/systems/methode-web-pub", "X-Request-Id": "tid_test"}
+
+	hook := logger.NewTestHook("")
+	err := mapper.Map(context.Background(), msg)
+
+	assert.NoError(t, err)
+	assert.Len(t, producer.messages, 1)
+
+	warned := false
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Message has validation problems, mapping it anyway." {
+			warned = true
+			assert.Equal(t, "false", entry.Data["isValid"].(string))
+			assert.Equal(t, []string{"tags[0].term.id: term has no id"}, entry.Data["validationProblems"])
+		}
+	}
+	assert.True(t, warned)
+
+	logLine := hook.LastEntry()
+	assert.Equal(t, "Successfully mapped", logLine.Message)
+	assert.Equal(t, "false", logLine.Data["isValid"].(string))
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/go-logger"
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/Shopify/sarama"
+)
+
+// The partition key strategies, choosing the key the concept annotations are produced with
+const (
+	contentUUIDKey   = "uuid"
+	transactionIDKey = "transactionId"
+	noKey            = "none"
+)
+
+const maxProducedMessageBytes = 16 * 1024 * 1024
+
+var errProducerNotConnected = errors.New("producer has not connected to Kafka yet")
+
+// partitionKeyFunc returns the key of a message given the content UUID it is about. Messages with the same key are
+// produced to the same partition, so they are consumed in order. An empty key leaves the choice of the partition to the producer.
+type partitionKeyFunc func(contentUUID string, message kafka.FTMessage) string
+
+var partitionKeyStrategies = map[string]partitionKeyFunc{
+	contentUUIDKey: func(contentUUID string, message kafka.FTMessage) string {
+		return contentUUID
+	},
+	transactionIDKey: func(contentUUID string, message kafka.FTMessage) string {
+		return message.Headers["X-Request-Id"]
+	},
+	noKey: func(contentUUID string, message kafka.FTMessage) string {
+		return ""
+	},
+}
+
+func newPartitionKeyFunc(strategy string) (partitionKeyFunc, error) {
+	keyFunc, found := partitionKeyStrategies[strategy]
+	if !found {
+		strategies := make([]string, 0, len(partitionKeyStrategies))
+		for name := range partitionKeyStrategies {
+			strategies = append(strategies, name)
+		}
+		sort.Strings(strategies)
+		return nil, fmt.Errorf("unknown partition key strategy %q, expected one of %s", strategy, strings.Join(strategies, ", "))
+	}
+	return keyFunc, nil
+}
+
+// keyedProducer is a producer that can choose the partition of a message by its key
+type keyedProducer interface {
+	kafka.Producer
+	SendKeyedMessage(key string, message kafka.FTMessage) error
+}
+
+// send produces the message with the partition key of the mapper's strategy, when the producer supports keys
+func (m *Mapper) send(contentUUID string, message kafka.FTMessage) error {
+	key := m.partitionKey(contentUUID, message)
+	if producer, ok := m.producer.(keyedProducer); ok && key != "" {
+		return producer.SendKeyedMessage(key, message)
+	}
+	return m.producer.SendMessage(message)
+}
+
+// saramaProducer produces messages to a topic, partitioned by the hash of their key.
+// Like the perseverant producer, it keeps trying to connect in the background until it succeeds or it is shut down.
+type saramaProducer struct {
+	brokers []string
+	topic   string
+	config  *sarama.Config
+	stop    chan struct{}
+	once    sync.Once
+
+	mutex    sync.RWMutex
+	client   sarama.Client
+	producer sarama.SyncProducer
+}
+
+func newKeyedProducer(brokers []string, topic string, retryInterval time.Duration) *saramaProducer {
+	p := &saramaProducer{brokers: brokers, topic: topic, config: newProducerSaramaConfig(), stop: make(chan struct{})}
+	go p.connect(retryInterval)
+	return p
+}
+
+// newProducerSaramaConfig starts from the configuration of the kafka-client-go producers,
+// so that keying the messages does not change how durably they are produced
+func newProducerSaramaConfig() *sarama.Config {
+	config := kafka.DefaultProducerConfig()
+	config.ClientID = serviceName
+	config.Producer.Partitioner = sarama.NewHashPartitioner
+	config.Producer.MaxMessageBytes = maxProducedMessageBytes
+	return config
+}
+
+func (p *saramaProducer) connect(retryInterval time.Duration) {
+	for {
+		err := p.tryConnect()
+		if err == nil {
+			logger.Infof(map[string]interface{}{"event": producerStartedEvent}, "Connected the producer of topic %s to Kafka", p.topic)
+			return
+		}
+		logger.Errorf(nil, err, "Cannot connect the producer of topic %s to Kafka, retrying in %v", p.topic, retryInterval)
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func (p *saramaProducer) tryConnect() error {
+	client, err := sarama.NewClient(p.brokers, p.config)
+	if err != nil {
+		return err
+	}
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	select {
+	case <-p.stop:
+		producer.Close()
+		client.Close()
+		return errors.New("the producer was shut down while connecting")
+	default:
+	}
+	p.client = client
+	p.producer = producer
+	return nil
+}
+
+// SendMessage produces the message without a key, to a partition chosen at random
+func (p *saramaProducer) SendMessage(message kafka.FTMessage) error {
+	return p.SendKeyedMessage("", message)
+}
+
+// SendKeyedMessage produces the message to the partition of its key
+func (p *saramaProducer) SendKeyedMessage(key string, message kafka.FTMessage) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.producer == nil {
+		return errProducerNotConnected
+	}
+
+	msg := &sarama.ProducerMessage{Topic: p.topic, Value: sarama.StringEncoder(message.Build())}
+	if key != "" {
+		msg.Key = sarama.StringEncoder(key)
+	}
+	_, _, err := p.producer.SendMessage(msg)
+	return err
+}
+
+// ConnectivityCheck checks that the brokers can be reached and know the produced topic
+func (p *saramaProducer) ConnectivityCheck() error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.client == nil {
+		return errProducerNotConnected
+	}
+	return p.client.RefreshMetadata(p.topic)
+}
+
+// Shutdown waits for the messages being produced and closes the connection
+func (p *saramaProducer) Shutdown() {
+	p.once.Do(func() { close(p.stop) })
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.producer != nil {
+		if err := p.producer.Close(); err != nil {
+			logger.NewEntry("").WithError(err).Error("Error closing the producer")
+		}
+		p.producer = nil
+	}
+	if p.client != nil {
+		if err := p.client.Close(); err != nil {
+			logger.NewEntry("").WithError(err).Error("Error closing the producer connection to Kafka")
+		}
+		p.client = nil
+	}
+}
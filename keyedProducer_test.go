@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionKeyStrategies(t *testing.T) {
+	message := kafka.FTMessage{Headers: map[string]string{"X-Request-Id": "tid_test"}}
+	tests := []struct {
+		strategy    string
+		expectedKey string
+	}{
+		{contentUUIDKey, "6f14ea94-690f-11e6-8d56-b74a3b6e4e70"},
+		{transactionIDKey, "tid_test"},
+		{noKey, ""},
+	}
+
+	for _, test := range tests {
+		keyFunc, err := newPartitionKeyFunc(test.strategy)
+		require.NoError(t, err, test.strategy)
+		assert.Equal(t, test.expectedKey, keyFunc("6f14ea94-690f-11e6-8d56-b74a3b6e4e70", message), test.strategy)
+	}
+
+	_, err := newPartitionKeyFunc("partition")
+	assert.EqualError(t, err, `unknown partition key strategy "partition", expected one of none, transactionId, uuid`)
+}
+
+type mockKeyedProducer struct {
+	mockProducer
+	keys []string
+}
+
+func (p *mockKeyedProducer) SendKeyedMessage(key string, message kafka.FTMessage) error {
+	p.keys = append(p.keys, key)
+	return p.SendMessage(message)
+}
+
+func TestHandleMessage__KeysMessagesByContentUUID(t *testing.T) {
+	producer := &mockKeyedProducer{}
+	mapper := newTestMapper(producer)
+
+	err := mapper.Map(context.Background(), publishEventMessage("6f14ea94-690f-11e6-8d56-b74a3b6e4e70", "tid_test"))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"6f14ea94-690f-11e6-8d56-b74a3b6e4e70"}, producer.keys)
+	assert.Len(t, producer.messages, 1)
+}
+
+func TestSendMessage__WithoutKey(t *testing.T) {
+	producer := &mockKeyedProducer{}
+	mapper := newTestMapper(producer)
+	mapper.partitionKey = partitionKeyStrategies[noKey]
+
+	err := mapper.send("6f14ea94-690f-11e6-8d56-b74a3b6e4e70", kafka.FTMessage{Body: "{}"})
+
+	require.NoError(t, err)
+	assert.Empty(t, producer.keys)
+	assert.Len(t, producer.messages, 1)
+}
+
+type mockSyncProducer struct {
+	messages []*sarama.ProducerMessage
+	closed   bool
+}
+
+func (p *mockSyncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	p.messages = append(p.messages, msg)
+	return 0, int64(len(p.messages)), nil
+}
+
+func (p *mockSyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	p.messages = append(p.messages, msgs...)
+	return nil
+}
+
+func (p *mockSyncProducer) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestSaramaProducer__SendKeyedMessage(t *testing.T) {
+	syncProducer := &mockSyncProducer{}
+	producer := &saramaProducer{topic: "ConceptAnnotations", stop: make(chan struct{}), producer: syncProducer}
+
+	require.NoError(t, producer.SendKeyedMessage("6f14ea94-690f-11e6-8d56-b74a3b6e4e70", kafka.FTMessage{Headers: map[string]string{"X-Request-Id": "tid_test"}, Body: "{}"}))
+	require.NoError(t, producer.SendMessage(kafka.FTMessage{Body: "{}"}))
+
+	require.Len(t, syncProducer.messages, 2)
+	keyed := syncProducer.messages[0]
+	assert.Equal(t, "ConceptAnnotations", keyed.Topic)
+	assert.Equal(t, sarama.StringEncoder("6f14ea94-690f-11e6-8d56-b74a3b6e4e70"), keyed.Key)
+	message := kafka.FTMessage{Headers: map[string]string{"X-Request-Id": "tid_test"}, Body: "{}"}
+	assert.Equal(t, sarama.StringEncoder(message.Build()), keyed.Value)
+	assert.Nil(t, syncProducer.messages[1].Key)
+
+	producer.Shutdown()
+	assert.True(t, syncProducer.closed)
+	assert.Equal(t, errProducerNotConnected, producer.SendMessage(kafka.FTMessage{Body: "{}"}))
+}
+
+func TestNewProducerSaramaConfig(t *testing.T) {
+	config := newProducerSaramaConfig()
+
+	assert.Equal(t, sarama.WaitForAll, config.Producer.RequiredAcks)
+	assert.Equal(t, 10, config.Producer.Retry.Max)
+	assert.True(t, config.Producer.Return.Successes)
+	assert.Equal(t, maxProducedMessageBytes, config.Producer.MaxMessageBytes)
+	assert.NoError(t, config.Validate())
+}
+
+func TestSaramaProducer__NotConnected(t *testing.T) {
+	producer := &saramaProducer{topic: "ConceptAnnotations", stop: make(chan struct{})}
+
+	assert.Equal(t, errProducerNotConnected, producer.ConnectivityCheck())
+	assert.Equal(t, errProducerNotConnected, producer.SendKeyedMessage("key", kafka.FTMessage{Body: "{}"}))
+}
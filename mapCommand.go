@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	logger "github.com/Financial-Times/go-logger"
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	cli "github.com/jawher/mow.cli"
+)
+
+const maxPublishEventSize = 16 * 1024 * 1024
+
+// mapCommand defines the map subcommand, which runs metadata publish events read from files through a Mapper,
+// exactly as if they had been consumed from the queue, and writes the concept annotations as JSON lines instead of producing them.
+func mapCommand(options mappingOptions) cli.CmdInitializer {
+	return func(cmd *cli.Cmd) {
+		input := cmd.String(cli.StringOpt{
+			Name:  "input",
+			Value: "-",
+			Desc:  "A file with one metadata publish event JSON per line, a directory of V1 metadata XML files named after the content UUID, or - for stdin",
+		})
+		output := cmd.String(cli.StringOpt{
+			Name:  "output",
+			Value: "-",
+			Desc:  "The file to write the concept annotations to, one JSON per line, or - for stdout",
+		})
+		originSystemID := cmd.String(cli.StringOpt{
+			Name:  "originSystemId",
+			Value: "http://cmdb.ft.com/systems/methode-web-pub",
+			Desc:  "The Origin-System-Id the events are treated as published by",
+		})
+
+		publishEventContentType := cmd.String(cli.StringOpt{
+			Name:  "contentType",
+			Value: "application/json",
+			Desc:  "The Content-Type the events read from a file or stdin are treated as published with, " + jsonMetadataContentType + " for JSON metadata",
+		})
+
+		cmd.Action = func() {
+			store, _, _ := configureMapping(options)
+
+			out := os.Stdout
+			if *output != "-" {
+				f, err := os.Create(*output)
+				if err != nil {
+					logger.Fatalf(nil, err, "Cannot create output file")
+				}
+				defer f.Close()
+				out = f
+			}
+
+			events, failed, err := mapPublishEvents(store, *input, out, *originSystemID, *publishEventContentType)
+			if err != nil {
+				logger.Fatalf(nil, err, "Cannot read publish events")
+			}
+			logger.Infof(nil, "Mapped %d metadata publish events, %d failed", events-failed, failed)
+			if failed > 0 {
+				cli.Exit(1)
+			}
+		}
+	}
+}
+
+// writerProducer writes the body of each message as a line, in place of the queue producer
+type writerProducer struct {
+	w io.Writer
+}
+
+func (p writerProducer) SendMessage(message kafka.FTMessage) error {
+	_, err := fmt.Fprintln(p.w, message.Body)
+	return err
+}
+
+func (p writerProducer) ConnectivityCheck() error {
+	return nil
+}
+
+func (p writerProducer) Shutdown() {}
+
+// mapPublishEvents maps the publish events read from input with the mapping configuration of the store, and writes the concept annotations to out.
+// The events read from a file or stdin have the given Content-Type, while the events of a directory of V1 metadata XML files always have XML metadata.
+// It returns the number of events read and the number of events that failed.
+func mapPublishEvents(store *configStore, input string, out io.Writer, originSystemID string, contentType string) (int, int, error) {
+	annotationsMapper := NewMapper(MapperDependencies{Producer: writerProducer{w: out}, Config: store})
+
+	events, failed := 0, 0
+	handle := func(body string) {
+		events++
+		msg := kafka.FTMessage{
+			Headers: map[string]string{
+				"Content-Type":     contentType,
+				"Origin-System-Id": originSystemID,
+				"X-Request-Id":     fmt.Sprintf("tid_map_%d", events),
+			},
+			Body: body,
+		}
+		if err := annotationsMapper.handleMessage(msg); err != nil {
+			failed++
+		}
+	}
+
+	if input == "-" {
+		err := readPublishEventLines(os.Stdin, handle)
+		return events, failed, err
+	}
+
+	info, err := os.Stat(input)
+	if err != nil {
+		return events, failed, err
+	}
+	if info.IsDir() {
+		contentType = "application/json"
+		err = readMetadataXMLDir(input, handle)
+		return events, failed, err
+	}
+
+	f, err := os.Open(input)
+	if err != nil {
+		return events, failed, err
+	}
+	defer f.Close()
+	err = readPublishEventLines(f, handle)
+	return events, failed, err
+}
+
+func readPublishEventLines(r io.Reader, handle func(body string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxPublishEventSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		handle(line)
+	}
+	return scanner.Err()
+}
+
+// readMetadataXMLDir wraps every XML file of the directory in a publish event, using the file name as the content UUID
+func readMetadataXMLDir(dir string, handle func(body string)) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.xml"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		metadataXML, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		event := MetadataPublishEvent{
+			UUID:  strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)),
+			Value: base64.StdEncoding.EncodeToString(metadataXML),
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		handle(string(body))
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readConceptAnnotationLines(t *testing.T, out *bytes.Buffer) []mapper.ConceptAnnotations {
+	var result []mapper.ConceptAnnotations
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var conceptAnnotations mapper.ConceptAnnotations
+		require.NoError(t, json.Unmarshal([]byte(line), &conceptAnnotations))
+		result = append(result, conceptAnnotations)
+	}
+	return result
+}
+
+func TestMapPublishEvents__JSONLines(t *testing.T) {
+	firstUUID, secondUUID := uuid.New(), uuid.New()
+	events := `{"uuid":"` + firstUUID + `","value":"` + validUTF8Metadata + `"}` + "\n" +
+		"\n" +
+		`{"uuid":"` + uuid.New() + `","value":"I AM NOT BASE64!"}` + "\n" +
+		`{"uuid":"` + secondUUID + `","value":"` + validUTF8Metadata + `"}` + "\n"
+
+	f, err := ioutil.TempFile("", "events-*.jsonl")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(events)
+	require.NoError(t, err)
+	f.Close()
+
+	out := &bytes.Buffer{}
+	total, failed, err := mapPublishEvents(newDefaultConfigStore(), f.Name(), out, "http://cmdb.ft.com/systems/methode-web-pub", "application/json")
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, 1, failed)
+
+	conceptAnnotations := readConceptAnnotationLines(t, out)
+	require.Len(t, conceptAnnotations, 2)
+	assert.Equal(t, firstUUID, conceptAnnotations[0].UUID)
+	assert.Equal(t, secondUUID, conceptAnnotations[1].UUID)
+	assert.Equal(t, conceptAnnotations[0].Annotations, conceptAnnotations[1].Annotations)
+	assert.NotEmpty(t, conceptAnnotations[0].Annotations)
+}
+
+func TestMapPublishEvents__MetadataXMLDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadata")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	metadataXML, err := base64.StdEncoding.DecodeString(validUTF8Metadata)
+	require.NoError(t, err)
+	testUUID := uuid.New()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, testUUID+".xml"), metadataXML, 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.txt"), []byte("not metadata"), 0644))
+
+	out := &bytes.Buffer{}
+	total, failed, err := mapPublishEvents(newDefaultConfigStore(), dir, out, "http://cmdb.ft.com/systems/methode-web-pub", "application/json")
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, 0, failed)
+
+	conceptAnnotations := readConceptAnnotationLines(t, out)
+	require.Len(t, conceptAnnotations, 1)
+	assert.Equal(t, testUUID, conceptAnnotations[0].UUID)
+	assert.NotEmpty(t, conceptAnnotations[0].Annotations)
+}
+
+func TestMapPublishEvents__MissingInput(t *testing.T) {
+	_, _, err := mapPublishEvents(newDefaultConfigStore(), "does-not-exist.jsonl", &bytes.Buffer{}, "http://cmdb.ft.com/systems/methode-web-pub", "application/json")
+	assert.Error(t, err)
+}
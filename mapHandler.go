@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	logger "github.com/Financial-Times/go-logger"
+	"github.com/Financial-Times/kafka-client-go/kafka"
+)
+
+// maxMapRequestBytes is the largest body POST /map reads, as large as the largest message written to the queue
+const maxMapRequestBytes = maxProducedMessageBytes
+
+type errorMessage struct {
+	Message    string              `json:"message"`
+	Problems   []validationProblem `json:"problems,omitempty"`
+	Violations []string            `json:"violations,omitempty"`
+}
+
+// mapHandler returns the concept annotations that would be written to the queue for the posted
+// metadata publish event, or for the posted raw V1 metadata XML or JSON metadata, without going through Kafka.
+// For raw metadata the content UUID can be given with the uuid query parameter, and for a publish event
+// the Content-Type of the message can be given with the contentType query parameter.
+func (m *Mapper) mapHandler(w http.ResponseWriter, r *http.Request) {
+	tid := r.Header.Get("X-Request-Id")
+	log := logger.NewEntry(tid)
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxMapRequestBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSONResponse(w, http.StatusRequestEntityTooLarge, errorMessage{Message: fmt.Sprintf("Request body is larger than %d bytes", tooLarge.Limit)})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Cannot read request body")
+		writeJSONResponse(w, http.StatusBadRequest, errorMessage{Message: "Cannot read request body"})
+		return
+	}
+
+	config := m.config.current()
+	uuid := r.URL.Query().Get("uuid")
+	metadataBytes := body
+	requestContentType := r.Header.Get("Content-Type")
+	decoder := metadataDecoderFor(requestContentType)
+	if _, isXML := decoder.(xmlMetadataDecoder); isXML && !isXMLContentType(requestContentType) {
+		headers := requestHeaders(r)
+		if publishEventContentType := r.URL.Query().Get("contentType"); publishEventContentType != "" {
+			headers["Content-Type"] = publishEventContentType
+		}
+		decoder = metadataDecoderFor(headers["Content-Type"])
+		if skipped := skippedPublishEvent(config, kafka.FTMessage{Headers: headers, Body: string(body)}); skipped != "" {
+			writeJSONResponse(w, http.StatusUnprocessableEntity, errorMessage{Message: skipped})
+			return
+		}
+
+		var metadataPublishEvent MetadataPublishEvent
+		if err = json.Unmarshal(body, &metadataPublishEvent); err != nil {
+			writeJSONResponse(w, http.StatusBadRequest, errorMessage{Message: "Cannot unmarshal message body: " + err.Error()})
+			return
+		}
+		uuid = metadataPublishEvent.UUID
+
+		metadataBytes, err = base64.StdEncoding.DecodeString(metadataPublishEvent.Value)
+		if err != nil {
+			writeJSONResponse(w, http.StatusBadRequest, errorMessage{Message: "Error decoding body: " + err.Error()})
+			return
+		}
+	}
+
+	metadata, err := decoder.decode(metadataBytes)
+	if err != nil {
+		errMsg := "Error unmarshalling metadata " + decoder.format()
+		if !utf8.Valid(metadataBytes) {
+			errMsg += ", metadata " + decoder.format() + " had invalid UTF8 characters"
+		}
+		writeJSONResponse(w, http.StatusBadRequest, errorMessage{Message: errMsg + ": " + err.Error()})
+		return
+	}
+
+	if problems := config.validator.validate(metadata); len(problems) > 0 && config.validationPolicy == rejectInvalidPolicy {
+		writeJSONResponse(w, http.StatusBadRequest, errorMessage{Message: "Metadata is not valid", Problems: problems})
+		return
+	}
+
+	marshalledAnnotations, err := config.output.write(mapper.MapToConceptAnnotations(config.taxonomies, uuid, metadata))
+	if err != nil {
+		log.WithError(err).Error("Error marshalling concept annotations")
+		writeJSONResponse(w, http.StatusInternalServerError, errorMessage{Message: "Error marshalling concept annotations"})
+		return
+	}
+
+	if config.schemaCheck == strictSchemaCheck {
+		if violations := config.output.schema().violations(marshalledAnnotations); len(violations) > 0 {
+			writeJSONResponse(w, http.StatusBadRequest, errorMessage{Message: "Concept annotations do not conform to the schema", Violations: violations})
+			return
+		}
+	}
+	writeJSONResponse(w, http.StatusOK, json.RawMessage(marshalledAnnotations))
+}
+
+// skippedPublishEvent tells why a posted metadata publish event would be skipped by the queue consumer, or returns an empty string
+// when it would be mapped. The headers of the request are the headers of the message, and the whitelist is only checked
+// when the request has an Origin-System-Id.
+func skippedPublishEvent(config *mappingConfig, msg kafka.FTMessage) string {
+	if systemCode, found := msg.Headers["Origin-System-Id"]; found && !config.whitelist.MatchString(systemCode) {
+		return fmt.Sprintf("Skipped: Origin-System-Id %q does not match the configured whitelist", systemCode)
+	}
+	if rule := config.filter.skippedBy(msg); rule != "" {
+		return fmt.Sprintf("Skipped: filtered out by rule %q", rule)
+	}
+	return ""
+}
+
+func requestHeaders(r *http.Request) map[string]string {
+	headers := make(map[string]string, len(r.Header))
+	for name := range r.Header {
+		headers[name] = r.Header.Get(name)
+	}
+	return headers
+}
+
+func isXMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/xml" || mediaType == "text/xml"
+}
+
+func writeJSONResponse(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.NewEntry("").WithError(err).Error("Error writing response")
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapHandler__MetadataPublishEvent(t *testing.T) {
+	testUUID := uuid.New()
+	body := `{"uuid":"` + testUUID + `","value":"` + validUTF8Metadata + `"}`
+
+	req := httptest.NewRequest("POST", "http://example.com/map", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestMapper(nil).mapHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var conceptAnnotations mapper.ConceptAnnotations
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &conceptAnnotations))
+	assert.Equal(t, testUUID, conceptAnnotations.UUID)
+	assert.Contains(t, conceptAnnotations.Annotations, mapper.Annotation{
+		Thing: mapper.Thing{
+			ID:        mapper.GenerateID("NjM=-U3ViamVjdHM="),
+			PrefLabel: "Economic News",
+			Predicate: mapper.IsClassifiedBy,
+			Types:     []string{mapper.SubjectType},
+		},
+		Provenance: []mapper.Provenance{{Scores: []mapper.Score{{ScoringSystem: mapper.RelevanceScoringSystem, Value: 1}, {ScoringSystem: mapper.ConfidenceScoringSystem, Value: 1}}}},
+	})
+}
+
+func TestMapHandler__RawMetadataXML(t *testing.T) {
+	testUUID := uuid.New()
+	metadataXML, _ := base64.StdEncoding.DecodeString(validUTF8Metadata)
+
+	req := httptest.NewRequest("POST", "http://example.com/map?uuid="+testUUID, strings.NewReader(string(metadataXML)))
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	w := httptest.NewRecorder()
+
+	newTestMapper(nil).mapHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var conceptAnnotations mapper.ConceptAnnotations
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &conceptAnnotations))
+	assert.Equal(t, testUUID, conceptAnnotations.UUID)
+	assert.NotEmpty(t, conceptAnnotations.Annotations)
+}
+
+func TestMapHandler__InvalidInput(t *testing.T) {
+	tests := []struct {
+		name            string
+		contentType     string
+		body            string
+		expectedMessage string
+	}{
+		{"Invalid JSON body", "application/json", ``, "Cannot unmarshal message body"},
+		{"Invalid base64 value", "application/json", `{"uuid":"` + uuid.New() + `","value":"I AM NOT BASE64!"}`, "Error decoding body"},
+		{"Invalid metadata XML", "application/json", `{"uuid":"` + uuid.New() + `","value":"eyJtc2ciOiJOb3QgWE1MIn0="}`, "Error unmarshalling metadata XML"},
+		{"Invalid raw metadata XML", "application/xml", `{"msg":"Not XML"}`, "Error unmarshalling metadata XML"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "http://example.com/map", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		w := httptest.NewRecorder()
+
+		newTestMapper(nil).mapHandler(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, test.name)
+
+		var msg errorMessage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg), test.name)
+		assert.True(t, strings.HasPrefix(msg.Message, test.expectedMessage), fmt.Sprintf("%s: unexpected message %q", test.name, msg.Message))
+	}
+}
+
+func TestMapHandler__UTF8(t *testing.T) {
+	tests := []struct {
+		name           string
+		metadataBase64 string
+		expectedStatus int
+	}{
+		{"Should map metadata with VALID UTF8 characters", validUTF8Metadata, http.StatusOK},
+		{"Should report metadata with INVALID UTF8 characters", invalidUTF8Metadata, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "http://example.com/map", strings.NewReader(`{"uuid":"`+uuid.New()+`","value":"`+test.metadataBase64+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		newTestMapper(nil).mapHandler(w, req)
+
+		assert.Equal(t, test.expectedStatus, w.Code, test.name)
+		if test.expectedStatus == http.StatusBadRequest {
+			assert.Contains(t, w.Body.String(), "metadata XML had invalid UTF8 characters", test.name)
+		}
+	}
+}
+
+func TestMapHandler__BodyTooLarge(t *testing.T) {
+	req := httptest.NewRequest("POST", "http://example.com/map", strings.NewReader(strings.Repeat(" ", maxMapRequestBytes+1)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestMapper(nil).mapHandler(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	var msg errorMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, fmt.Sprintf("Request body is larger than %d bytes", maxMapRequestBytes), msg.Message)
+}
+
+func TestMapHandler__ValidationProblemsAreRejected(t *testing.T) {
+	m := newTestMapper(nil)
+	setTestConfig(m, func(config *mappingConfig) { config.validationPolicy = rejectInvalidPolicy })
+
+	req := httptest.NewRequest("POST", "http://example.com/map", strings.NewReader(invalidMetadataXML))
+	req.Header.Set("Content-Type", "application/xml")
+	w := httptest.NewRecorder()
+
+	m.mapHandler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response errorMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Metadata is not valid", response.Message)
+	assert.Equal(t, []validationProblem{{Code: emptyTermIDProblem, Field: "tags[0].term.id", Message: "term has no id"}}, response.Problems)
+}
+
+func TestMapHandler__StrictSchemaCheckRejectsNonConformingAnnotations(t *testing.T) {
+	m := newTestMapper(nil)
+	setTestConfig(m, func(config *mappingConfig) { config.schemaCheck = strictSchemaCheck })
+
+	req := httptest.NewRequest("POST", "http://example.com/map", strings.NewReader(`{"uuid":"not-a-uuid","value":"`+validUTF8Metadata+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	m.mapHandler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var msg errorMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, "Concept annotations do not conform to the schema", msg.Message)
+	assert.NotEmpty(t, msg.Violations)
+}
+
+func TestMapHandler__SkippedPublishEvents(t *testing.T) {
+	tests := []struct {
+		name            string
+		headers         map[string]string
+		contentUUID     string
+		expectedMessage string
+	}{
+		{
+			"Origin-System-Id not in the whitelist",
+			map[string]string{"Origin-System-Id": "http://cmdb.ft.com/systems/pac"},
+			uuid.New(),
+			`Skipped: Origin-System-Id "http://cmdb.ft.com/systems/pac" does not match the configured whitelist`,
+		},
+		{
+			"Filtered out",
+			map[string]string{},
+			"f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2",
+			`Skipped: filtered out by rule "quarantined"`,
+		},
+	}
+
+	m := newTestMapper(nil)
+	filter := testMessageFilter(t, testFilterRules)
+	setTestConfig(m, func(config *mappingConfig) { config.filter = filter })
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "http://example.com/map", strings.NewReader(`{"uuid":"`+test.contentUUID+`","value":"`+validUTF8Metadata+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range test.headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+
+		m.mapHandler(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, test.name)
+		var msg errorMessage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg), test.name)
+		assert.Equal(t, test.expectedMessage, msg.Message, test.name)
+	}
+
+	req := httptest.NewRequest("POST", "http://example.com/map", strings.NewReader(`{"uuid":"`+uuid.New()+`","value":"`+validUTF8Metadata+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin-System-Id", "http://cmdb.ft.com/systems/methode-web-pub")
+	w := httptest.NewRecorder()
+	m.mapHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	logger "github.com/Financial-Times/go-logger"
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/twinj/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MapperDependencies are what a Mapper maps and writes the concept annotations with. Only the producer and the configuration are required.
+type MapperDependencies struct {
+	// Producer writes the concept annotations
+	Producer kafka.Producer
+	// DeadLetterProducer writes the messages that cannot be mapped. They are dropped when it is nil.
+	DeadLetterProducer kafka.Producer
+	// Config holds the mapping configuration: the whitelist, the filter rules, the taxonomy mapping rules, the validation and the output format
+	Config *configStore
+	// PartitionKey chooses the key of the concept annotations. They are keyed by content UUID when it is nil.
+	PartitionKey partitionKeyFunc
+	// Retry retries failing produces. The default produce retry policy is used when it has no attempts.
+	Retry retryPolicy
+	// Duplicates skips concept annotations produced unchanged recently. Nothing is skipped when it is nil.
+	Duplicates *duplicateFilter
+	// Clock tells the time of the produced and dead-letter messages. It is time.Now when nil.
+	Clock func() time.Time
+	// NewID generates the Message-Id of the produced messages. It is a random UUID when nil.
+	NewID func() string
+	// Tracer starts the spans of the mapping stages. It is the tracer of the global tracer provider when nil.
+	Tracer trace.Tracer
+	// Propagator reads the trace context from the consumed messages and writes it to the produced ones. It uses the W3C trace context headers when nil.
+	Propagator propagation.TextMapPropagator
+}
+
+// Mapper maps metadata publish events to concept annotations and writes them to its producer.
+// It holds everything it needs, so mappers with different producers and configurations can run in the same process.
+type Mapper struct {
+	producer     kafka.Producer
+	deadLetter   kafka.Producer
+	config       *configStore
+	partitionKey partitionKeyFunc
+	retry        retryPolicy
+	duplicates   *duplicateFilter
+	now          func() time.Time
+	newID        func() string
+	tracer       trace.Tracer
+	propagator   propagation.TextMapPropagator
+}
+
+// NewMapper creates a Mapper with the dependencies, using the defaults for the optional ones that are not given
+func NewMapper(deps MapperDependencies) *Mapper {
+	m := &Mapper{
+		producer:     deps.Producer,
+		deadLetter:   deps.DeadLetterProducer,
+		config:       deps.Config,
+		partitionKey: deps.PartitionKey,
+		retry:        deps.Retry,
+		duplicates:   deps.Duplicates,
+		now:          deps.Clock,
+		newID:        deps.NewID,
+		tracer:       deps.Tracer,
+		propagator:   deps.Propagator,
+	}
+	if m.partitionKey == nil {
+		m.partitionKey = partitionKeyStrategies[contentUUIDKey]
+	}
+	if m.retry.maxAttempts == 0 {
+		m.retry = newProduceRetryPolicy(defaultProduceAttempts, defaultProduceRetryBackoff)
+	}
+	if m.now == nil {
+		m.now = time.Now
+	}
+	if m.newID == nil {
+		m.newID = func() string { return uuid.NewV4().String() }
+	}
+	if m.tracer == nil {
+		m.tracer = otel.Tracer(tracerName)
+	}
+	if m.propagator == nil {
+		m.propagator = defaultPropagator()
+	}
+	return m
+}
+
+// handleMessage maps the messages of the map subcommand, which does not give them a context
+func (m *Mapper) handleMessage(msg kafka.FTMessage) error {
+	return m.Map(context.Background(), msg)
+}
+
+// Map maps the metadata publish event to concept annotations and writes them to the producer. Messages that cannot be mapped
+// are written to the dead-letter producer, and their error is returned. The context stops the retries of a failing producer.
+// The mapping is traced as a child of the trace context of the message headers, which is passed on to the concept annotations.
+func (m *Mapper) Map(ctx context.Context, msg kafka.FTMessage) error {
+	messagesConsumed.Inc()
+	defer prometheus.NewTimer(handleMessageDuration).ObserveDuration()
+
+	ctx = m.propagator.Extract(ctx, headersCarrier(msg.Headers))
+	ctx, span := m.tracer.Start(ctx, "map publish event", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+		attribute.String(transactionIDAttribute, msg.Headers["X-Request-Id"]),
+		attribute.String(originSystemAttribute, msg.Headers["Origin-System-Id"]),
+	))
+	err := m.mapMessage(ctx, msg)
+	endSpan(span, err)
+	return err
+}
+
+type consumedAtKey struct{}
+
+// withConsumedAt records in the context when the message was consumed, for the messages that wait before being mapped
+func withConsumedAt(ctx context.Context, consumedAt time.Time) context.Context {
+	return context.WithValue(ctx, consumedAtKey{}, consumedAt)
+}
+
+func consumedAtFrom(ctx context.Context) (time.Time, bool) {
+	consumedAt, found := ctx.Value(consumedAtKey{}).(time.Time)
+	return consumedAt, found
+}
+
+func (m *Mapper) mapMessage(ctx context.Context, msg kafka.FTMessage) error {
+	span := trace.SpanFromContext(ctx)
+	tid := msg.Headers["X-Request-Id"]
+	log := logger.NewEntry(tid)
+	config := m.config.current()
+	consumedAt, found := consumedAtFrom(ctx)
+	if !found {
+		consumedAt = m.now()
+	}
+
+	systemCode := msg.Headers["Origin-System-Id"]
+	if !config.whitelist.MatchString(systemCode) {
+		messagesSkipped.Inc()
+		span.SetAttributes(attribute.String(skippedAttribute, "whitelist"))
+		log.Infof("Skipping annotations published with Origin-System-Id \"%v\". It does not match the configured whitelist.", systemCode)
+		return nil
+	}
+
+	if rule := config.filter.skippedBy(msg); rule != "" {
+		messagesFiltered.WithLabelValues(rule).Inc()
+		span.SetAttributes(attribute.String(skippedAttribute, "filter"))
+		log.WithField("filterRule", rule).Infof("Skipping message with Origin-System-Id \"%v\" filtered out by rule \"%v\".", systemCode, rule)
+		return nil
+	}
+
+	// Messages that cannot be parsed are invalid. Parsed metadata is then validated, and depending on the validation policy
+	// messages with validation problems are either rejected or mapped anyway, still being logged as invalid.
+
+	// Consider the message as invalid - logging all the error messages for this transaction as monitoring events
+	msgIsValid := false
+
+	var metadataPublishEvent MetadataPublishEvent
+	_, stageSpan := m.tracer.Start(ctx, "unmarshal")
+	err := json.Unmarshal([]byte(msg.Body), &metadataPublishEvent)
+	endSpan(stageSpan, err)
+	if err != nil {
+		logger.NewMonitoringEntry(mapperEvent, tid, contentType).WithValidFlag(msgIsValid).WithError(err).Error("Cannot unmarshal message body")
+		messagesFailed.WithLabelValues(jsonStage).Inc()
+		m.sendToDeadLetter(msg, jsonStage, err)
+		return err
+	}
+
+	span.SetAttributes(attribute.String(contentUUIDAttribute, metadataPublishEvent.UUID))
+	log.WithUUID(metadataPublishEvent.UUID).Info("Processing metadata publish event")
+
+	decoder := metadataDecoderFor(msg.Headers["Content-Type"])
+	_, stageSpan = m.tracer.Start(ctx, "decode", trace.WithAttributes(attribute.String(metadataFormatAttribute, decoder.format())))
+	metadataBytes, err := base64.StdEncoding.DecodeString(metadataPublishEvent.Value)
+	if err != nil {
+		endSpan(stageSpan, err)
+		logger.NewMonitoringEntry(mapperEvent, tid, contentType).WithValidFlag(msgIsValid).WithUUID(metadataPublishEvent.UUID).WithError(err).Error("Error decoding body")
+		messagesFailed.WithLabelValues(base64Stage).Inc()
+		m.sendToDeadLetter(msg, base64Stage, err)
+		return err
+	}
+
+	metadata, err := decoder.decode(metadataBytes)
+	endSpan(stageSpan, err)
+	if err != nil {
+		errMsg := "Error unmarshalling metadata " + decoder.format()
+		if !utf8.Valid(metadataBytes) {
+			logger.NewEntry(tid).WithUUID(metadataPublishEvent.UUID).WithError(err).Errorf("%s Metadata %s had invalid UTF8 characters.", errMsg, decoder.format())
+		} else {
+			logger.NewEntry(tid).WithUUID(metadataPublishEvent.UUID).WithError(err).Errorf("%s", errMsg)
+		}
+
+		// Log validation error as a monitoring event
+		entry := logger.NewMonitoringEntry(mapperEvent, tid, contentType).WithValidFlag(msgIsValid)
+		if metadataPublishEvent.UUID != "" {
+			entry = entry.WithUUID(metadataPublishEvent.UUID)
+		}
+		messagesFailed.WithLabelValues(decoder.stage()).Inc()
+		m.sendToDeadLetter(msg, decoder.stage(), err)
+		entry.WithError(err).Error("Message is not valid due to parsing issues.")
+		return err
+	}
+
+	_, stageSpan = m.tracer.Start(ctx, "validate")
+	problems := config.validator.validate(metadata)
+	stageSpan.SetAttributes(attribute.Int(problemsAttribute, len(problems)))
+	if len(problems) > 0 {
+		err = validationError{problems: problems}
+		if config.validationPolicy == rejectInvalidPolicy {
+			endSpan(stageSpan, err)
+			messagesFailed.WithLabelValues(validationStage).Inc()
+			m.sendToDeadLetter(msg, validationStage, err)
+			logger.NewMonitoringEntry(mapperEvent, tid, contentType).WithUUID(metadataPublishEvent.UUID).WithValidFlag(msgIsValid).WithError(err).Error("Message is not valid due to validation problems.")
+			return err
+		}
+		logger.NewMonitoringEntry(mapperEvent, tid, contentType).WithUUID(metadataPublishEvent.UUID).WithValidFlag(msgIsValid).
+			WithField("validationProblems", describeProblems(problems)).Warn("Message has validation problems, mapping it anyway.")
+	}
+	stageSpan.End()
+
+	// if the message had no parsing errors nor validation problems: consider it as valid
+	msgIsValid = len(problems) == 0
+	annotationsByTaxonomy := m.mapTaxonomies(ctx, config.taxonomies, metadata)
+	conceptAnnotations := mapper.ConceptAnnotations{UUID: metadataPublishEvent.UUID, Annotations: mapper.FlattenAnnotations(annotationsByTaxonomy)}
+	span.SetAttributes(attribute.Int(annotationsAttribute, len(conceptAnnotations.Annotations)))
+
+	_, stageSpan = m.tracer.Start(ctx, "marshal")
+	marshalledAnnotations, err := config.output.write(conceptAnnotations)
+	if err != nil {
+		endSpan(stageSpan, err)
+		logger.NewMonitoringEntry(mapperEvent, tid, contentType).WithUUID(metadataPublishEvent.UUID).WithValidFlag(msgIsValid).WithError(err).Error("Error marshalling concept annotations")
+		messagesFailed.WithLabelValues(marshalStage).Inc()
+		return err
+	}
+
+	if config.schemaCheck != offSchemaCheck {
+		if violations := config.output.schema().violations(marshalledAnnotations); len(violations) > 0 {
+			schemaViolations.Inc()
+			err = schemaViolationError{violations: violations}
+			if config.schemaCheck == strictSchemaCheck {
+				endSpan(stageSpan, err)
+				messagesFailed.WithLabelValues(schemaStage).Inc()
+				m.sendToDeadLetter(msg, schemaStage, err)
+				logger.NewMonitoringEntry(mapperEvent, tid, contentType).WithUUID(metadataPublishEvent.UUID).WithValidFlag(msgIsValid).WithError(err).Error("Concept annotations do not conform to the schema.")
+				return err
+			}
+			logger.NewMonitoringEntry(mapperEvent, tid, contentType).WithUUID(metadataPublishEvent.UUID).WithValidFlag(msgIsValid).
+				WithField("schemaViolations", violations).Warn("Concept annotations do not conform to the schema, writing them anyway.")
+		}
+	}
+	stageSpan.End()
+
+	annotationsHash := hashConceptAnnotations(marshalledAnnotations)
+	if m.duplicates != nil && !isForcedRepublish(msg.Headers) && m.duplicates.isDuplicate(metadataPublishEvent.UUID, annotationsHash) {
+		messagesUnchanged.Inc()
+		span.SetAttributes(attribute.String(skippedAttribute, "duplicate"))
+		log.WithUUID(metadataPublishEvent.UUID).Info("Skipping concept annotations unchanged since they were last produced")
+		return nil
+	}
+
+	produceCtx, stageSpan := m.tracer.Start(ctx, "produce", trace.WithSpanKind(trace.SpanKindProducer))
+	var headers = buildConceptAnnotationsHeader(msg.Headers, config.headers, m.newID(), m.now())
+	m.propagator.Inject(produceCtx, headersCarrier(headers))
+	message := kafka.FTMessage{Headers: headers, Body: string(marshalledAnnotations)}
+	attempts, err := m.retry.do(produceCtx, func() error {
+		return m.send(metadataPublishEvent.UUID, message)
+	}, func(attempt int, err error, backoff time.Duration) {
+		produceRetries.Inc()
+		log.WithUUID(metadataPublishEvent.UUID).WithError(err).Warnf("Error sending concept annotations to queue on attempt %d, retrying in %v", attempt, backoff)
+	})
+	produceAttempts.Observe(float64(attempts))
+	stageSpan.SetAttributes(attribute.Int(attemptsAttribute, attempts))
+	endSpan(stageSpan, err)
+	if err != nil {
+		logger.NewMonitoringEntry(mapperEvent, tid, contentType).WithUUID(metadataPublishEvent.UUID).WithValidFlag(msgIsValid).
+			WithField("attempts", attempts).WithError(err).Error("Error sending concept annotations to queue")
+		messagesFailed.WithLabelValues(produceStage).Inc()
+		m.sendToDeadLetter(msg, produceStage, err)
+		return err
+	}
+
+	if m.duplicates != nil {
+		m.duplicates.remember(metadataPublishEvent.UUID, annotationsHash)
+	}
+	messagesMapped.Inc()
+	countProducedAnnotations(annotationsByTaxonomy, conceptAnnotations.Annotations)
+
+	lag := observeLag(msg.Headers, consumedAt, m.now())
+	logger.NewMonitoringEntry(mapperEvent, tid, contentType).WithUUID(metadataPublishEvent.UUID).
+		WithValidFlag(msgIsValid).WithFields(lag).Info("Successfully mapped")
+	return nil
+}
+
+// mapTaxonomies maps the metadata with every taxonomy service of the registry, tracing each of them
+func (m *Mapper) mapTaxonomies(ctx context.Context, registry *mapper.TaxonomyRegistry, metadata mapper.ContentRef) map[string][]mapper.Annotation {
+	ctx, span := m.tracer.Start(ctx, "map taxonomies")
+	defer span.End()
+
+	names := registry.Names()
+	annotationsByTaxonomy := make(map[string][]mapper.Annotation, len(names))
+	for _, name := range names {
+		service, _ := registry.Service(name)
+		_, taxonomySpan := m.tracer.Start(ctx, "map "+name, trace.WithAttributes(attribute.String(taxonomyAttribute, name)))
+		annotationsByTaxonomy[name] = service.BuildAnnotations(metadata)
+		taxonomySpan.SetAttributes(attribute.Int(annotationsAttribute, len(annotationsByTaxonomy[name])))
+		taxonomySpan.End()
+	}
+	return annotationsByTaxonomy
+}
@@ -0,0 +1,98 @@
+package mapper
+
+// predicateFamilies groups the predicates that conflict with each other when used for the same concept.
+// Predicates outside of these families never conflict with any other predicate.
+var predicateFamilies = map[string]string{
+	About:                   "mentions",
+	MajorMentions:           "mentions",
+	Mentions:                "mentions",
+	IsPrimarilyClassifiedBy: "classification",
+	IsClassifiedBy:          "classification",
+}
+
+func predicateFamily(predicate string) string {
+	if family, found := predicateFamilies[predicate]; found {
+		return family
+	}
+	return predicate
+}
+
+// consolidateAnnotations collapses the annotations that refer to the same concept with the same or
+// conflicting predicates into a single annotation. The strongest predicate is kept, the types are combined
+// and the provenance scores are merged, keeping the highest score of each scoring system.
+func consolidateAnnotations(annotations []Annotation) []Annotation {
+	consolidated := []Annotation{}
+	positions := make(map[string]int, len(annotations))
+
+	for _, a := range annotations {
+		key := a.Thing.ID + " " + predicateFamily(a.Thing.Predicate)
+		i, found := positions[key]
+		if !found {
+			positions[key] = len(consolidated)
+			consolidated = append(consolidated, copyAnnotation(a))
+			continue
+		}
+		consolidated[i] = mergeAnnotations(consolidated[i], a)
+	}
+	return consolidated
+}
+
+func mergeAnnotations(a Annotation, b Annotation) Annotation {
+	if rankPredicate(b.Thing.Predicate) < rankPredicate(a.Thing.Predicate) {
+		a.Thing.Predicate = b.Thing.Predicate
+	}
+	if a.Thing.PrefLabel == "" {
+		a.Thing.PrefLabel = b.Thing.PrefLabel
+	}
+	for _, t := range b.Thing.Types {
+		if !containsString(a.Thing.Types, t) {
+			a.Thing.Types = append(a.Thing.Types, t)
+		}
+	}
+	a.Provenance = mergeProvenances(a.Provenance, b.Provenance)
+	return a
+}
+
+func mergeProvenances(a []Provenance, b []Provenance) []Provenance {
+	var scores []Score
+	positions := make(map[string]int)
+	for _, p := range append(append([]Provenance{}, a...), b...) {
+		for _, s := range p.Scores {
+			i, found := positions[s.ScoringSystem]
+			if !found {
+				positions[s.ScoringSystem] = len(scores)
+				scores = append(scores, s)
+				continue
+			}
+			if s.Value > scores[i].Value {
+				scores[i].Value = s.Value
+			}
+		}
+	}
+	if len(scores) == 0 {
+		return nil
+	}
+	return []Provenance{{Scores: scores}}
+}
+
+// copyAnnotation copies the slices of an annotation, so merging into it does not change the original
+func copyAnnotation(a Annotation) Annotation {
+	a.Thing.Types = append([]string{}, a.Thing.Types...)
+	if a.Provenance != nil {
+		provenances := make([]Provenance, len(a.Provenance))
+		for i, p := range a.Provenance {
+			provenances[i] = Provenance{Scores: append([]Score{}, p.Scores...)}
+		}
+		a.Provenance = provenances
+	}
+	return a
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
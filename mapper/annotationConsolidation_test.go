@@ -0,0 +1,98 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsolidateAnnotations(t *testing.T) {
+	lowScores := []Provenance{{Scores: []Score{{ScoringSystem: RelevanceScoringSystem, Value: 0.5}, {ScoringSystem: ConfidenceScoringSystem, Value: 0.9}}}}
+	highScores := []Provenance{{Scores: []Score{{ScoringSystem: RelevanceScoringSystem, Value: 0.8}, {ScoringSystem: ConfidenceScoringSystem, Value: 0.6}}}}
+	mergedScores := []Provenance{{Scores: []Score{{ScoringSystem: RelevanceScoringSystem, Value: 0.8}, {ScoringSystem: ConfidenceScoringSystem, Value: 0.9}}}}
+
+	tests := []struct {
+		name     string
+		input    []Annotation
+		expected []Annotation
+	}{
+		{
+			"Same term tagged twice",
+			[]Annotation{
+				{Thing: Thing{ID: "1", PrefLabel: "Economic News", Predicate: IsClassifiedBy, Types: []string{SubjectType}}, Provenance: lowScores},
+				{Thing: Thing{ID: "1", PrefLabel: "Economic News", Predicate: IsClassifiedBy, Types: []string{SubjectType}}, Provenance: highScores},
+			},
+			[]Annotation{
+				{Thing: Thing{ID: "1", PrefLabel: "Economic News", Predicate: IsClassifiedBy, Types: []string{SubjectType}}, Provenance: mergedScores},
+			},
+		},
+		{
+			"Tag that is also the primary theme",
+			[]Annotation{
+				{Thing: Thing{ID: "2", PrefLabel: "New York", Predicate: MajorMentions, Types: []string{LocationType}}, Provenance: lowScores},
+				{Thing: Thing{ID: "2", PrefLabel: "New York", Predicate: About, Types: []string{LocationType}}},
+			},
+			[]Annotation{
+				{Thing: Thing{ID: "2", PrefLabel: "New York", Predicate: About, Types: []string{LocationType}}, Provenance: lowScores},
+			},
+		},
+		{
+			"Tag that is also the primary section",
+			[]Annotation{
+				{Thing: Thing{ID: "3", PrefLabel: "Companies", Predicate: IsPrimarilyClassifiedBy, Types: []string{SectionType}}},
+				{Thing: Thing{ID: "3", PrefLabel: "Companies", Predicate: IsClassifiedBy, Types: []string{SectionType}}, Provenance: highScores},
+			},
+			[]Annotation{
+				{Thing: Thing{ID: "3", PrefLabel: "Companies", Predicate: IsPrimarilyClassifiedBy, Types: []string{SectionType}}, Provenance: highScores},
+			},
+		},
+		{
+			"Same concept with predicates that do not conflict",
+			[]Annotation{
+				{Thing: Thing{ID: "4", PrefLabel: "Martin Wolf", Predicate: HasAuthor, Types: []string{AuthorType}}, Provenance: lowScores},
+				{Thing: Thing{ID: "4", PrefLabel: "Martin Wolf", Predicate: MajorMentions, Types: []string{PersonType}}, Provenance: highScores},
+			},
+			[]Annotation{
+				{Thing: Thing{ID: "4", PrefLabel: "Martin Wolf", Predicate: HasAuthor, Types: []string{AuthorType}}, Provenance: lowScores},
+				{Thing: Thing{ID: "4", PrefLabel: "Martin Wolf", Predicate: MajorMentions, Types: []string{PersonType}}, Provenance: highScores},
+			},
+		},
+		{
+			"Same concept mapped with different types",
+			[]Annotation{
+				{Thing: Thing{ID: "5", PrefLabel: "FT", Predicate: IsClassifiedBy, Types: []string{BrandType}}, Provenance: lowScores},
+				{Thing: Thing{ID: "5", PrefLabel: "FT", Predicate: IsClassifiedBy, Types: []string{GenreType}}},
+			},
+			[]Annotation{
+				{Thing: Thing{ID: "5", PrefLabel: "FT", Predicate: IsClassifiedBy, Types: []string{BrandType, GenreType}}, Provenance: lowScores},
+			},
+		},
+		{
+			"Different concepts",
+			[]Annotation{
+				{Thing: Thing{ID: "6", PrefLabel: "News", Predicate: IsClassifiedBy, Types: []string{GenreType}}, Provenance: lowScores},
+				{Thing: Thing{ID: "7", PrefLabel: "Letter", Predicate: IsClassifiedBy, Types: []string{GenreType}}, Provenance: highScores},
+			},
+			[]Annotation{
+				{Thing: Thing{ID: "6", PrefLabel: "News", Predicate: IsClassifiedBy, Types: []string{GenreType}}, Provenance: lowScores},
+				{Thing: Thing{ID: "7", PrefLabel: "Letter", Predicate: IsClassifiedBy, Types: []string{GenreType}}, Provenance: highScores},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, consolidateAnnotations(test.input), test.name)
+	}
+}
+
+func TestConsolidateAnnotationsDoesNotChangeItsInput(t *testing.T) {
+	input := []Annotation{
+		{Thing: Thing{ID: "1", Predicate: IsClassifiedBy, Types: []string{BrandType}}, Provenance: []Provenance{{Scores: []Score{{ScoringSystem: RelevanceScoringSystem, Value: 0.5}}}}},
+		{Thing: Thing{ID: "1", Predicate: IsClassifiedBy, Types: []string{GenreType}}, Provenance: []Provenance{{Scores: []Score{{ScoringSystem: RelevanceScoringSystem, Value: 0.8}}}}},
+	}
+
+	consolidateAnnotations(input)
+
+	assert.Equal(t, []string{BrandType}, input[0].Thing.Types)
+	assert.Equal(t, float32(0.5), input[0].Provenance[0].Scores[0].Value)
+}
@@ -0,0 +1,45 @@
+package mapper
+
+import (
+	"sort"
+	"strings"
+)
+
+// predicatePriority ranks the predicates from the strongest to the weakest; annotations are written in this order
+var predicatePriority = map[string]int{
+	About:                   0,
+	IsPrimarilyClassifiedBy: 1,
+	MajorMentions:           2,
+	IsClassifiedBy:          3,
+	Mentions:                4,
+	HasAuthor:               5,
+}
+
+// sortAnnotations orders annotations by predicate priority, then by type, then by concept ID,
+// so the same metadata always produces the same output
+func sortAnnotations(annotations []Annotation) {
+	sort.SliceStable(annotations, func(i, j int) bool {
+		a, b := annotations[i].Thing, annotations[j].Thing
+		if pa, pb := rankPredicate(a.Predicate), rankPredicate(b.Predicate); pa != pb {
+			return pa < pb
+		}
+		if a.Predicate != b.Predicate {
+			return a.Predicate < b.Predicate
+		}
+		if ta, tb := strings.Join(a.Types, ","), strings.Join(b.Types, ","); ta != tb {
+			return ta < tb
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.PrefLabel < b.PrefLabel
+	})
+}
+
+// rankPredicate returns the priority of a predicate, unknown predicates come after all the known ones
+func rankPredicate(predicate string) int {
+	if priority, found := predicatePriority[predicate]; found {
+		return priority
+	}
+	return len(predicatePriority)
+}
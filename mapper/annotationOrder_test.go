@@ -0,0 +1,51 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortAnnotations(t *testing.T) {
+	annotations := []Annotation{
+		{Thing: Thing{ID: "http://api.ft.com/things/3", Predicate: HasAuthor, Types: []string{AuthorType}}},
+		{Thing: Thing{ID: "http://api.ft.com/things/2", Predicate: IsClassifiedBy, Types: []string{SubjectType}}},
+		{Thing: Thing{ID: "http://api.ft.com/things/1", Predicate: IsClassifiedBy, Types: []string{SubjectType}}},
+		{Thing: Thing{ID: "http://api.ft.com/things/4", Predicate: "implicitlyAbout", Types: []string{TopicType}}},
+		{Thing: Thing{ID: "http://api.ft.com/things/5", Predicate: IsClassifiedBy, Types: []string{GenreType}}},
+		{Thing: Thing{ID: "http://api.ft.com/things/6", Predicate: MajorMentions, Types: []string{PersonType}}},
+		{Thing: Thing{ID: "http://api.ft.com/things/7", Predicate: About, Types: []string{LocationType}}},
+		{Thing: Thing{ID: "http://api.ft.com/things/8", Predicate: IsPrimarilyClassifiedBy, Types: []string{SectionType}}},
+		{Thing: Thing{ID: "http://api.ft.com/things/9", Predicate: Mentions, Types: []string{OrganisationType}}},
+	}
+
+	sortAnnotations(annotations)
+
+	var ids []string
+	for _, a := range annotations {
+		ids = append(ids, a.Thing.ID)
+	}
+	assert.Equal(t, []string{
+		"http://api.ft.com/things/7",
+		"http://api.ft.com/things/8",
+		"http://api.ft.com/things/6",
+		"http://api.ft.com/things/5",
+		"http://api.ft.com/things/1",
+		"http://api.ft.com/things/2",
+		"http://api.ft.com/things/9",
+		"http://api.ft.com/things/3",
+		"http://api.ft.com/things/4",
+	}, ids)
+}
+
+func TestMapConceptAnnotationsIsDeterministic(t *testing.T) {
+	metadata := readTestMetadata(t, "metadata.xml")
+	registry, err := NewTaxonomyRegistry(DefaultTaxonomyRules)
+	require.NoError(t, err)
+
+	expected := MapToConceptAnnotations(registry, "uuid", metadata)
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, expected, MapToConceptAnnotations(registry, "uuid", metadata))
+	}
+}
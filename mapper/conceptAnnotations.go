@@ -0,0 +1,32 @@
+package mapper
+
+// ConceptAnnotations models the annotations of a content as they are written to the queue
+type ConceptAnnotations struct {
+	UUID        string       `json:"uuid"`
+	Annotations []Annotation `json:"annotations"`
+}
+
+// Annotation relates the content to a concept
+type Annotation struct {
+	Thing      Thing        `json:"thing"`
+	Provenance []Provenance `json:"provenances,omitempty"`
+}
+
+// Thing is the annotated concept, and how the content relates to it
+type Thing struct {
+	ID        string   `json:"id"`
+	PrefLabel string   `json:"prefLabel"`
+	Predicate string   `json:"predicate"`
+	Types     []string `json:"types"`
+}
+
+// Provenance holds the scores of an annotation
+type Provenance struct {
+	Scores []Score `json:"scores"`
+}
+
+// Score is the value of an annotation in a scoring system
+type Score struct {
+	ScoringSystem string  `json:"scoringSystem"`
+	Value         float32 `json:"value"`
+}
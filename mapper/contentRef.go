@@ -0,0 +1,41 @@
+package mapper
+
+import "encoding/xml"
+
+// ContentRef models the data as it comes from the metadata publishing event
+type ContentRef struct {
+	TagHolder      Tags `xml:"tags"`
+	PrimarySection Term `xml:"primarySection"`
+	PrimaryTheme   Term `xml:"primaryTheme"`
+}
+
+// Tags holds the tags of a ContentRef
+type Tags struct {
+	Tags []Tag `xml:"tag"`
+}
+
+// Tag is a term the content is tagged with, and how relevant it is to the content
+type Tag struct {
+	Term     Term     `xml:"term" json:"term"`
+	TagScore TagScore `xml:"score" json:"score"`
+}
+
+// Term is a concept of a V1 taxonomy
+type Term struct {
+	CanonicalName string `xml:"canonicalName" json:"canonicalName"`
+	Taxonomy      string `xml:"taxonomy,attr" json:"taxonomy"`
+	ID            string `xml:"id,attr" json:"id"`
+}
+
+// TagScore holds the relevance and confidence of a tag, as percentages
+type TagScore struct {
+	Confidence int `xml:"confidence,attr" json:"confidence"`
+	Relevance  int `xml:"relevance,attr" json:"relevance"`
+}
+
+// ParseV1Metadata parses the V1 metadata XML of a content
+func ParseV1Metadata(metadataXML []byte) (ContentRef, error) {
+	metadata := ContentRef{}
+	err := xml.Unmarshal(metadataXML, &metadata)
+	return metadata, err
+}
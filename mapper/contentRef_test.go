@@ -0,0 +1,41 @@
+package mapper
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestMetadata(t *testing.T, name string) ContentRef {
+	metadataXML, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	metadata, err := ParseV1Metadata(metadataXML)
+	require.NoError(t, err)
+	return metadata
+}
+
+func TestParseV1Metadata(t *testing.T) {
+	metadata := readTestMetadata(t, "metadata.xml")
+
+	assert.NotEmpty(t, metadata.TagHolder.Tags)
+	for _, tag := range metadata.TagHolder.Tags {
+		assert.NotEmpty(t, tag.Term.Taxonomy)
+		assert.NotEmpty(t, tag.Term.ID)
+	}
+}
+
+func TestParseV1Metadata__InvalidUTF8(t *testing.T) {
+	metadataXML, err := ioutil.ReadFile(filepath.Join("testdata", "invalid-utf8-metadata.xml"))
+	require.NoError(t, err)
+
+	_, err = ParseV1Metadata(metadataXML)
+	assert.Error(t, err)
+}
+
+func TestParseV1Metadata__NotXML(t *testing.T) {
+	_, err := ParseV1Metadata([]byte(`{"msg":"Not XML"}`))
+	assert.Error(t, err)
+}
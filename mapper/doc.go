@@ -0,0 +1,6 @@
+// Package mapper maps the V1 metadata of FT content to UPP concept annotations.
+//
+// ParseV1Metadata parses the ContentRef of the V1 metadata XML, and MapToConceptAnnotations maps it with the taxonomy services
+// of a TaxonomyRegistry. The registry is built from taxonomy rules, DefaultTaxonomyRules or ones loaded with LoadTaxonomyRules,
+// and other TaxonomyService implementations can be registered with it.
+package mapper
@@ -0,0 +1,78 @@
+package mapper_test
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+)
+
+func ExampleMapToConceptAnnotations() {
+	metadataXML := []byte(`<ContentRef><tags>
+		<tag><term id="NjM=-U3ViamVjdHM=" taxonomy="Subjects"><canonicalName>Economic News</canonicalName></term><score confidence="90" relevance="80"/></tag>
+	</tags></ContentRef>`)
+
+	metadata, err := mapper.ParseV1Metadata(metadataXML)
+	if err != nil {
+		panic(err)
+	}
+	registry, err := mapper.NewTaxonomyRegistry(mapper.DefaultTaxonomyRules)
+	if err != nil {
+		panic(err)
+	}
+	conceptAnnotations := mapper.MapToConceptAnnotations(registry, "f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2", metadata)
+
+	for _, a := range conceptAnnotations.Annotations {
+		fmt.Println(a.Thing.PrefLabel, a.Thing.Predicate, a.Thing.Types)
+	}
+	// Output: Economic News isClassifiedBy [http://www.ft.com/ontology/Subject]
+}
+
+// speakerService annotates the speakers of a content, tagged in the Speakers taxonomy, as mentioned people
+type speakerService struct{}
+
+func (speakerService) BuildAnnotations(contentRef mapper.ContentRef) []mapper.Annotation {
+	annotations := []mapper.Annotation{}
+	for _, tag := range contentRef.TagHolder.Tags {
+		if tag.Term.Taxonomy == "Speakers" {
+			annotations = append(annotations, mapper.Annotation{Thing: mapper.Thing{
+				ID:        mapper.GenerateID(tag.Term.ID),
+				PrefLabel: tag.Term.CanonicalName,
+				Predicate: mapper.Mentions,
+				Types:     []string{mapper.PersonType},
+			}})
+		}
+	}
+	return annotations
+}
+
+func ExampleTaxonomyRegistry_Register() {
+	registry, err := mapper.NewTaxonomyRegistry(mapper.DefaultTaxonomyRules)
+	if err != nil {
+		panic(err)
+	}
+	registry.Register("speakers", speakerService{})
+
+	metadata := mapper.ContentRef{TagHolder: mapper.Tags{Tags: []mapper.Tag{
+		{Term: mapper.Term{CanonicalName: "Jane Doe", Taxonomy: "Speakers", ID: "Speaker-1-TME"}},
+	}}}
+	conceptAnnotations := mapper.MapToConceptAnnotations(registry, "f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2", metadata)
+
+	for _, a := range conceptAnnotations.Annotations {
+		fmt.Println(a.Thing.PrefLabel, a.Thing.Predicate, a.Thing.Types)
+	}
+	// Output: Jane Doe mentions [http://www.ft.com/ontology/person/Person]
+}
+
+func ExampleLoadTaxonomyRules() {
+	rules, err := mapper.LoadTaxonomyRules(filepath.Join("testdata", "taxonomies.json"))
+	if err != nil {
+		panic(err)
+	}
+	registry, err := mapper.NewTaxonomyRegistry(rules)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(registry.Names())
+	// Output: [specialists topics]
+}
@@ -0,0 +1,96 @@
+package mapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// taxonomyConfig models the taxonomy mapping configuration file
+type taxonomyConfig struct {
+	Taxonomies []TaxonomyRule `json:"taxonomies"`
+}
+
+var knownPredicates = map[string]bool{
+	Mentions:                true,
+	MajorMentions:           true,
+	IsClassifiedBy:          true,
+	IsPrimarilyClassifiedBy: true,
+	About:                   true,
+	HasAuthor:               true,
+}
+
+// LoadTaxonomyRules reads the taxonomy mapping rules from a JSON file and validates them
+func LoadTaxonomyRules(path string) ([]TaxonomyRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	var config taxonomyConfig
+	if err = dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("cannot parse taxonomy configuration %s: %v", path, err)
+	}
+
+	if err = ValidateTaxonomyRules(config.Taxonomies); err != nil {
+		return nil, fmt.Errorf("invalid taxonomy configuration %s: %v", path, err)
+	}
+	return config.Taxonomies, nil
+}
+
+// ValidateTaxonomyRules checks that there are taxonomy rules, that their names are unique, and that their types, predicates
+// and primary terms are valid
+func ValidateTaxonomyRules(rules []TaxonomyRule) error {
+	if len(rules) == 0 {
+		return fmt.Errorf("no taxonomies are configured")
+	}
+
+	names := make(map[string]bool, len(rules))
+	primaryTaxonomies := make(map[string]string)
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("taxonomy rule %d has no name", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("taxonomy rule %q is defined more than once", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if rule.Taxonomy == "" {
+			return fmt.Errorf("taxonomy rule %q has no taxonomy", rule.Name)
+		}
+		if u, err := url.Parse(rule.Type); err != nil || !u.IsAbs() || u.Host == "" {
+			return fmt.Errorf("taxonomy rule %q has an invalid type URI %q", rule.Name, rule.Type)
+		}
+		if !knownPredicates[rule.Predicate] {
+			return fmt.Errorf("taxonomy rule %q has an unknown predicate %q", rule.Name, rule.Predicate)
+		}
+
+		if rule.Primary == nil {
+			continue
+		}
+		if rule.Primary.Field != PrimarySectionField && rule.Primary.Field != PrimaryThemeField {
+			return fmt.Errorf("taxonomy rule %q has an unknown primary field %q, expected %q or %q", rule.Name, rule.Primary.Field, PrimarySectionField, PrimaryThemeField)
+		}
+		if !knownPredicates[rule.Primary.Predicate] {
+			return fmt.Errorf("taxonomy rule %q has an unknown primary predicate %q", rule.Name, rule.Primary.Predicate)
+		}
+
+		for _, taxonomy := range rule.primaryTaxonomies() {
+			if taxonomy == "" {
+				return fmt.Errorf("taxonomy rule %q has an empty primary taxonomy", rule.Name)
+			}
+			key := rule.Primary.Field + "/" + strings.ToLower(taxonomy)
+			if other, found := primaryTaxonomies[key]; found && other != rule.Name {
+				return fmt.Errorf("taxonomy rules %q and %q both map the %s of taxonomy %q", other, rule.Name, rule.Primary.Field, taxonomy)
+			}
+			primaryTaxonomies[key] = rule.Name
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package mapper
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTaxonomyConfig(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "taxonomies-*.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	return f.Name()
+}
+
+func TestDefaultTaxonomyRulesAreValid(t *testing.T) {
+	assert.NoError(t, ValidateTaxonomyRules(DefaultTaxonomyRules))
+}
+
+func TestLoadTaxonomyRules(t *testing.T) {
+	path := writeTaxonomyConfig(t, `{
+		"taxonomies": [
+			{"name": "specialists", "taxonomy": "Specialist", "type": "http://www.ft.com/ontology/Specialist", "predicate": "isClassifiedBy"},
+			{"name": "topics", "taxonomy": "Topics", "type": "http://www.ft.com/ontology/Topic", "predicate": "mentions",
+				"primary": {"field": "primaryTheme", "predicate": "about"}}
+		]
+	}`)
+	defer os.Remove(path)
+
+	rules, err := LoadTaxonomyRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, []TaxonomyRule{
+		{Name: "specialists", Taxonomy: "Specialist", Type: "http://www.ft.com/ontology/Specialist", Predicate: IsClassifiedBy},
+		{Name: "topics", Taxonomy: "Topics", Type: TopicType, Predicate: Mentions,
+			Primary: &PrimaryRule{Field: PrimaryThemeField, Predicate: About}},
+	}, rules)
+
+	registry, err := NewTaxonomyRegistry(rules)
+	require.NoError(t, err)
+	contentRef := ContentRef{
+		TagHolder: Tags{Tags: []Tag{
+			{Term: Term{CanonicalName: "Economics Editor", Taxonomy: "Specialist", ID: "Specialist-1-TME"}, TagScore: testScore},
+		}},
+	}
+	annotations := registry.AnnotationsByTaxonomy(contentRef)["specialists"]
+	require.Len(t, annotations, 1)
+	assert.Equal(t, Thing{
+		ID:        GenerateID("Specialist-1-TME"),
+		PrefLabel: "Economics Editor",
+		Predicate: IsClassifiedBy,
+		Types:     []string{"http://www.ft.com/ontology/Specialist"},
+	}, annotations[0].Thing)
+}
+
+func TestLoadTaxonomyRules__InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Not JSON", `taxonomies:`},
+		{"Unknown field", `{"taxonomies": [{"name": "subjects", "taxonomy": "Subjects", "type": "http://www.ft.com/ontology/Subject", "predicate": "isClassifiedBy", "colour": "red"}]}`},
+		{"No taxonomies", `{"taxonomies": []}`},
+		{"Missing name", `{"taxonomies": [{"taxonomy": "Subjects", "type": "http://www.ft.com/ontology/Subject", "predicate": "isClassifiedBy"}]}`},
+		{"Duplicate name", `{"taxonomies": [
+			{"name": "subjects", "taxonomy": "Subjects", "type": "http://www.ft.com/ontology/Subject", "predicate": "isClassifiedBy"},
+			{"name": "subjects", "taxonomy": "Genres", "type": "http://www.ft.com/ontology/Genre", "predicate": "isClassifiedBy"}]}`},
+		{"Missing taxonomy", `{"taxonomies": [{"name": "subjects", "type": "http://www.ft.com/ontology/Subject", "predicate": "isClassifiedBy"}]}`},
+		{"Relative type", `{"taxonomies": [{"name": "subjects", "taxonomy": "Subjects", "type": "Subject", "predicate": "isClassifiedBy"}]}`},
+		{"Unknown predicate", `{"taxonomies": [{"name": "subjects", "taxonomy": "Subjects", "type": "http://www.ft.com/ontology/Subject", "predicate": "isAbout"}]}`},
+		{"Unknown primary field", `{"taxonomies": [{"name": "topics", "taxonomy": "Topics", "type": "http://www.ft.com/ontology/Topic", "predicate": "majorMentions",
+			"primary": {"field": "primaryTopic", "predicate": "about"}}]}`},
+		{"Primary term mapped twice", `{"taxonomies": [
+			{"name": "topics", "taxonomy": "Topics", "type": "http://www.ft.com/ontology/Topic", "predicate": "majorMentions", "primary": {"field": "primaryTheme", "predicate": "about"}},
+			{"name": "themes", "taxonomy": "topics", "type": "http://www.ft.com/ontology/Topic", "predicate": "mentions", "primary": {"field": "primaryTheme", "predicate": "about"}}]}`},
+		{"Primary term of another name of the taxonomy mapped twice", `{"taxonomies": [
+			{"name": "organisations", "taxonomy": "ON", "type": "http://www.ft.com/ontology/organisation/Organisation", "predicate": "majorMentions",
+				"primary": {"field": "primaryTheme", "predicate": "about", "taxonomies": ["Organisations"]}},
+			{"name": "companies", "taxonomy": "Organisations", "type": "http://www.ft.com/ontology/organisation/Organisation", "predicate": "mentions",
+				"primary": {"field": "primaryTheme", "predicate": "about"}}]}`},
+		{"Empty primary taxonomy", `{"taxonomies": [{"name": "people", "taxonomy": "PN", "type": "http://www.ft.com/ontology/person/Person", "predicate": "majorMentions",
+			"primary": {"field": "primaryTheme", "predicate": "about", "taxonomies": [""]}}]}`},
+		{"Unknown primary predicate", `{"taxonomies": [{"name": "topics", "taxonomy": "Topics", "type": "http://www.ft.com/ontology/Topic", "predicate": "majorMentions",
+			"primary": {"field": "primaryTheme", "predicate": "isAbout"}}]}`},
+	}
+
+	for _, test := range tests {
+		path := writeTaxonomyConfig(t, test.content)
+		_, err := LoadTaxonomyRules(path)
+		assert.Error(t, err, test.name)
+		os.Remove(path)
+	}
+}
+
+func TestLoadTaxonomyRules__MissingFile(t *testing.T) {
+	_, err := LoadTaxonomyRules("does-not-exist.json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package mapper
+
+import "strings"
+
+// The types of the concepts of the default taxonomies, and the fields of the primary terms of a ContentRef
+const (
+	SubjectType          = "http://www.ft.com/ontology/Subject"
+	SectionType          = "http://www.ft.com/ontology/Section"
+	TopicType            = "http://www.ft.com/ontology/Topic"
+	LocationType         = "http://www.ft.com/ontology/Location"
+	GenreType            = "http://www.ft.com/ontology/Genre"
+	SpecialReportType    = "http://www.ft.com/ontology/SpecialReport"
+	AlphavilleSeriesType = "http://www.ft.com/ontology/AlphavilleSeries"
+	OrganisationType     = "http://www.ft.com/ontology/organisation/Organisation"
+	PersonType           = "http://www.ft.com/ontology/person/Person"
+	AuthorType           = "http://www.ft.com/ontology/person/Person"
+	BrandType            = "http://www.ft.com/ontology/Brand"
+
+	PrimarySectionField = "primarySection"
+	PrimaryThemeField   = "primaryTheme"
+)
+
+// TaxonomyRule describes how the tags of a V1 taxonomy are mapped to annotations
+type TaxonomyRule struct {
+	Name      string       `json:"name"`
+	Taxonomy  string       `json:"taxonomy"`
+	Type      string       `json:"type"`
+	Predicate string       `json:"predicate"`
+	Primary   *PrimaryRule `json:"primary,omitempty"`
+}
+
+// PrimaryRule describes how the primary section or primary theme of the content is mapped for a taxonomy.
+// Taxonomies lists the other names the taxonomy can have in the taxonomy attribute of the primary term.
+type PrimaryRule struct {
+	Field      string   `json:"field"`
+	Predicate  string   `json:"predicate"`
+	Taxonomies []string `json:"taxonomies,omitempty"`
+}
+
+// DefaultTaxonomyRules are the taxonomies the V1 metadata is mapped with when no taxonomy mapping configuration is given
+var DefaultTaxonomyRules = []TaxonomyRule{
+	{Name: "subjects", Taxonomy: "subjects", Type: SubjectType, Predicate: IsClassifiedBy},
+	{Name: "sections", Taxonomy: "sections", Type: SectionType, Predicate: IsClassifiedBy,
+		Primary: &PrimaryRule{Field: PrimarySectionField, Predicate: IsPrimarilyClassifiedBy}},
+	{Name: "topics", Taxonomy: "topics", Type: TopicType, Predicate: MajorMentions,
+		Primary: &PrimaryRule{Field: PrimaryThemeField, Predicate: About}},
+	{Name: "locations", Taxonomy: "gl", Type: LocationType, Predicate: MajorMentions,
+		Primary: &PrimaryRule{Field: PrimaryThemeField, Predicate: About}},
+	{Name: "genres", Taxonomy: "genres", Type: GenreType, Predicate: IsClassifiedBy},
+	{Name: "specialReports", Taxonomy: "specialReports", Type: SpecialReportType, Predicate: IsClassifiedBy,
+		Primary: &PrimaryRule{Field: PrimarySectionField, Predicate: IsPrimarilyClassifiedBy}},
+	{Name: "alphavilleSeries", Taxonomy: "alphavilleSeriesClassification", Type: AlphavilleSeriesType, Predicate: IsClassifiedBy},
+	{Name: "organisations", Taxonomy: "ON", Type: OrganisationType, Predicate: MajorMentions,
+		Primary: &PrimaryRule{Field: PrimaryThemeField, Predicate: About, Taxonomies: []string{"Organisations"}}},
+	{Name: "people", Taxonomy: "PN", Type: PersonType, Predicate: MajorMentions,
+		Primary: &PrimaryRule{Field: PrimaryThemeField, Predicate: About, Taxonomies: []string{"People"}}},
+	{Name: "authors", Taxonomy: "Authors", Type: AuthorType, Predicate: HasAuthor},
+	{Name: "brands", Taxonomy: "Brands", Type: BrandType, Predicate: IsClassifiedBy},
+}
+
+// taxonomyMappingService extracts and transforms the tags of the taxonomy described by its rule into annotations
+type taxonomyMappingService struct {
+	rule TaxonomyRule
+}
+
+// BuildAnnotations builds a list of annotations from a ContentRef.
+// Returns an empty array in case no annotations are found for the taxonomy
+func (service taxonomyMappingService) BuildAnnotations(contentRef ContentRef) []Annotation {
+	tags := extractTags(service.rule.Taxonomy, contentRef)
+	annotations := []Annotation{}
+
+	for _, value := range tags {
+		annotations = append(annotations, buildAnnotation(value, service.rule.Type, service.rule.Predicate))
+	}
+
+	if service.rule.Primary == nil {
+		return annotations
+	}
+
+	primaryTerm := contentRef.PrimaryTheme
+	if service.rule.Primary.Field == PrimarySectionField {
+		primaryTerm = contentRef.PrimarySection
+	}
+	// the primary term is mapped only by the rule for its own taxonomy, so it is annotated once with the right type
+	if primaryTerm.CanonicalName != "" && service.rule.mapsPrimaryTaxonomy(primaryTerm.Taxonomy) {
+		thing := Thing{
+			ID:        GenerateID(primaryTerm.ID),
+			PrefLabel: primaryTerm.CanonicalName,
+			Predicate: service.rule.Primary.Predicate,
+			Types:     []string{service.rule.Type},
+		}
+		annotations = append(annotations, Annotation{Thing: thing})
+	}
+
+	return annotations
+}
+
+// primaryTaxonomies returns the names the taxonomy of the rule can have in the taxonomy attribute of a primary term
+func (rule TaxonomyRule) primaryTaxonomies() []string {
+	return append([]string{rule.Taxonomy}, rule.Primary.Taxonomies...)
+}
+
+func (rule TaxonomyRule) mapsPrimaryTaxonomy(taxonomy string) bool {
+	for _, name := range rule.primaryTaxonomies() {
+		if strings.EqualFold(taxonomy, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewTaxonomyMappingService returns the TaxonomyService mapping the tags of the taxonomy described by the rule
+func NewTaxonomyMappingService(rule TaxonomyRule) TaxonomyService {
+	return taxonomyMappingService{rule: rule}
+}
@@ -0,0 +1,76 @@
+package mapper
+
+import "sort"
+
+// TaxonomyRegistry holds the taxonomy services the metadata is mapped with, by name.
+// Register services before mapping with the registry: it is not safe to change it while it maps metadata.
+type TaxonomyRegistry struct {
+	services map[string]TaxonomyService
+}
+
+// NewTaxonomyRegistry validates the taxonomy rules and registers a taxonomy mapping service for each of them
+func NewTaxonomyRegistry(rules []TaxonomyRule) (*TaxonomyRegistry, error) {
+	if err := ValidateTaxonomyRules(rules); err != nil {
+		return nil, err
+	}
+	registry := &TaxonomyRegistry{services: make(map[string]TaxonomyService, len(rules))}
+	for _, rule := range rules {
+		registry.Register(rule.Name, NewTaxonomyMappingService(rule))
+	}
+	return registry, nil
+}
+
+// Register adds a taxonomy service to the registry, replacing the one registered with the same name
+func (r *TaxonomyRegistry) Register(name string, service TaxonomyService) {
+	if r.services == nil {
+		r.services = make(map[string]TaxonomyService)
+	}
+	r.services[name] = service
+}
+
+// Names returns the names of the registered taxonomy services, in order
+func (r *TaxonomyRegistry) Names() []string {
+	names := make([]string, 0, len(r.services))
+	for name := range r.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Service returns the taxonomy service registered with the name
+func (r *TaxonomyRegistry) Service(name string) (TaxonomyService, bool) {
+	service, found := r.services[name]
+	return service, found
+}
+
+// AnnotationsByTaxonomy runs the metadata through every registered taxonomy service, and returns their annotations by name
+func (r *TaxonomyRegistry) AnnotationsByTaxonomy(metadata ContentRef) map[string][]Annotation {
+	annotationsByTaxonomy := make(map[string][]Annotation, len(r.services))
+	for name, service := range r.services {
+		annotationsByTaxonomy[name] = service.BuildAnnotations(metadata)
+	}
+	return annotationsByTaxonomy
+}
+
+// FlattenAnnotations combines the annotations of all the taxonomies into the consolidated, ordered list that is written to the queue
+func FlattenAnnotations(annotationsByTaxonomy map[string][]Annotation) []Annotation {
+	names := make([]string, 0, len(annotationsByTaxonomy))
+	for name := range annotationsByTaxonomy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	annotations := []Annotation{}
+	for _, name := range names {
+		annotations = append(annotations, annotationsByTaxonomy[name]...)
+	}
+	annotations = consolidateAnnotations(annotations)
+	sortAnnotations(annotations)
+	return annotations
+}
+
+// MapToConceptAnnotations maps the metadata of a content with every taxonomy service of the registry
+func MapToConceptAnnotations(registry *TaxonomyRegistry, uuid string, metadata ContentRef) ConceptAnnotations {
+	return ConceptAnnotations{UUID: uuid, Annotations: FlattenAnnotations(registry.AnnotationsByTaxonomy(metadata))}
+}
@@ -0,0 +1,73 @@
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedTaxonomyService annotates every content with the same concept
+type fixedTaxonomyService struct {
+	annotation Annotation
+}
+
+func (s fixedTaxonomyService) BuildAnnotations(ContentRef) []Annotation {
+	return []Annotation{s.annotation}
+}
+
+func TestNewTaxonomyRegistry(t *testing.T) {
+	registry, err := NewTaxonomyRegistry(DefaultTaxonomyRules)
+
+	require.NoError(t, err)
+	assert.Len(t, registry.Names(), len(DefaultTaxonomyRules))
+	assert.Equal(t, "alphavilleSeries", registry.Names()[0])
+
+	_, err = NewTaxonomyRegistry(nil)
+	assert.Error(t, err)
+}
+
+func TestTaxonomyRegistry__Register(t *testing.T) {
+	registry, err := NewTaxonomyRegistry([]TaxonomyRule{{Name: "subjects", Taxonomy: "Subjects", Type: SubjectType, Predicate: IsClassifiedBy}})
+	require.NoError(t, err)
+	fixed := Annotation{Thing: Thing{ID: GenerateID("Fixed-1-TME"), PrefLabel: "Fixed", Predicate: About, Types: []string{TopicType}}}
+
+	registry.Register("fixed", fixedTaxonomyService{annotation: fixed})
+	conceptAnnotations := MapToConceptAnnotations(registry, "f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2", buildContentRefWithSubjects(1))
+
+	assert.Equal(t, []string{"fixed", "subjects"}, registry.Names())
+	assert.Equal(t, "f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2", conceptAnnotations.UUID)
+	require.Len(t, conceptAnnotations.Annotations, 2)
+	assert.Equal(t, fixed, conceptAnnotations.Annotations[0], "about is the strongest predicate")
+	assert.Equal(t, buildConceptAnnotationsWithSubjects(1)[0], conceptAnnotations.Annotations[1])
+}
+
+func TestTaxonomyRegistry__RegisterOnAnEmptyRegistry(t *testing.T) {
+	registry := &TaxonomyRegistry{}
+
+	registry.Register("fixed", fixedTaxonomyService{})
+
+	assert.Equal(t, []string{"fixed"}, registry.Names())
+}
+
+func TestTaxonomyRegistry__Service(t *testing.T) {
+	registry := &TaxonomyRegistry{}
+	registry.Register("fixed", fixedTaxonomyService{})
+
+	service, found := registry.Service("fixed")
+	assert.True(t, found)
+	assert.Equal(t, fixedTaxonomyService{}, service)
+
+	_, found = registry.Service("subjects")
+	assert.False(t, found)
+}
+
+func TestMapToConceptAnnotations__NoAnnotations(t *testing.T) {
+	registry, err := NewTaxonomyRegistry(DefaultTaxonomyRules)
+	require.NoError(t, err)
+
+	conceptAnnotations := MapToConceptAnnotations(registry, "f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2", ContentRef{})
+
+	assert.NotNil(t, conceptAnnotations.Annotations)
+	assert.Empty(t, conceptAnnotations.Annotations)
+}
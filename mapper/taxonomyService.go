@@ -0,0 +1,69 @@
+package mapper
+
+import (
+	uuidutils "github.com/Financial-Times/uuid-utils-go"
+	"strings"
+)
+
+// TaxonomyService defines the operations used to process taxonomies
+type TaxonomyService interface {
+	// BuildAnnotations builds the annotations of the taxonomy from a ContentRef. It returns an empty slice when there are none.
+	BuildAnnotations(ContentRef) []Annotation
+}
+
+// The predicates of the annotations, and the scoring systems of their provenance scores
+const (
+	Mentions                = "mentions"
+	MajorMentions           = "majorMentions"
+	IsClassifiedBy          = "isClassifiedBy"
+	IsPrimarilyClassifiedBy = "isPrimarilyClassifiedBy"
+	About                   = "about"
+	HasAuthor               = "hasAuthor"
+
+	RelevanceScoringSystem  = "http://api.ft.com/scoringsystem/FT-RELEVANCE-SYSTEM"
+	ConfidenceScoringSystem = "http://api.ft.com/scoringsystem/FT-CONFIDENCE-SYSTEM"
+)
+
+func transformScore(score int) float32 {
+	return float32(score) / float32(100.0)
+}
+
+// GenerateID returns the UPP concept ID of a V1 term ID, a name based UUID under the things API
+func GenerateID(cmrTermID string) string {
+	return "http://api.ft.com/things/" + uuidutils.NewV3UUID(cmrTermID).String()
+}
+
+func extractTags(wantedTagName string, contentRef ContentRef) []Tag {
+	var wantedTags []Tag
+	for _, tag := range contentRef.TagHolder.Tags {
+		if strings.EqualFold(tag.Term.Taxonomy, wantedTagName) {
+			wantedTags = append(wantedTags, tag)
+		}
+	}
+	return wantedTags
+}
+
+func buildAnnotation(tag Tag, thingType string, predicate string) Annotation {
+	relevance := Score{
+		ScoringSystem: RelevanceScoringSystem,
+		Value:         transformScore(tag.TagScore.Relevance),
+	}
+	confidence := Score{
+		ScoringSystem: ConfidenceScoringSystem,
+		Value:         transformScore(tag.TagScore.Confidence),
+	}
+
+	provenances := []Provenance{
+		Provenance{
+			Scores: []Score{relevance, confidence},
+		},
+	}
+	thing := Thing{
+		ID:        GenerateID(tag.Term.ID),
+		PrefLabel: tag.Term.CanonicalName,
+		Predicate: predicate,
+		Types:     []string{thingType},
+	}
+
+	return Annotation{Thing: thing, Provenance: provenances}
+}
@@ -1,4 +1,4 @@
-package main
+package mapper
 
 import (
 	"fmt"
@@ -12,11 +12,11 @@ import (
 
 func TestSubjectServiceBuildAnnotations(t *testing.T) {
 	assert := assert.New(t)
-	service := SubjectService{"subjects"}
+	service := defaultTaxonomyService("subjects", "subjects")
 	tests := []struct {
 		name        string
 		contentRef  ContentRef
-		annotations []annotation
+		annotations []Annotation
 	}{
 		{"Build concept annotation from a contentRef with 1 subject tag",
 			buildContentRefWithSubjects(1),
@@ -33,18 +33,18 @@ func TestSubjectServiceBuildAnnotations(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actualConceptAnnotations := service.buildAnnotations(test.contentRef)
+		actualConceptAnnotations := service.BuildAnnotations(test.contentRef)
 		assert.Equal(test.annotations, actualConceptAnnotations, fmt.Sprintf("%s: Actual concept annotations incorrect", test.name))
 	}
 }
 
 func TestSectionServiceBuildAnnotations(t *testing.T) {
 	assert := assert.New(t)
-	service := SectionService{"sections"}
+	service := defaultTaxonomyService("sections", "sections")
 	tests := []struct {
 		name        string
 		contentRef  ContentRef
-		annotations []annotation
+		annotations []Annotation
 	}{
 		{"Build concept annotation from a contentRef with 1 section tag",
 			buildContentRefWithSections(1),
@@ -65,18 +65,18 @@ func TestSectionServiceBuildAnnotations(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actualConceptAnnotations := service.buildAnnotations(test.contentRef)
+		actualConceptAnnotations := service.BuildAnnotations(test.contentRef)
 		assert.Equal(test.annotations, actualConceptAnnotations, fmt.Sprintf("%s: Actual concept annotations incorrect", test.name))
 	}
 }
 
 func TestTopicServiceBuildAnnotations(t *testing.T) {
 	assert := assert.New(t)
-	service := TopicService{"topics"}
+	service := defaultTaxonomyService("topics", "topics")
 	tests := []struct {
 		name        string
 		contentRef  ContentRef
-		annotations []annotation
+		annotations []Annotation
 	}{
 		{"Build concept annotation from a contentRef with 1 topic tag",
 			buildContentRefWithTopics(1),
@@ -97,18 +97,18 @@ func TestTopicServiceBuildAnnotations(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actualConceptAnnotations := service.buildAnnotations(test.contentRef)
+		actualConceptAnnotations := service.BuildAnnotations(test.contentRef)
 		assert.Equal(test.annotations, actualConceptAnnotations, fmt.Sprintf("%s: Actual concept annotations incorrect", test.name))
 	}
 }
 
 func TestLocationServiceBuildAnnotations(t *testing.T) {
 	assert := assert.New(t)
-	service := LocationService{"gl"}
+	service := defaultTaxonomyService("locations", "gl")
 	tests := []struct {
 		name        string
 		contentRef  ContentRef
-		annotations []annotation
+		annotations []Annotation
 	}{
 		{"Build concept annotation from a contentRef with 1 location tag",
 			buildContentRefWithLocations(1),
@@ -129,18 +129,18 @@ func TestLocationServiceBuildAnnotations(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actualConceptAnnotations := service.buildAnnotations(test.contentRef)
+		actualConceptAnnotations := service.BuildAnnotations(test.contentRef)
 		assert.Equal(test.annotations, actualConceptAnnotations, fmt.Sprintf("%s: Actual concept annotations incorrect", test.name))
 	}
 }
 
 func TestGenreServiceBuildAnnotations(t *testing.T) {
 	assert := assert.New(t)
-	service := GenreService{"genres"}
+	service := defaultTaxonomyService("genres", "genres")
 	tests := []struct {
 		name        string
 		contentRef  ContentRef
-		annotations []annotation
+		annotations []Annotation
 	}{
 		{"Build concept annotation from a contentRef with 1 genre tag",
 			buildContentRefWithGenres(1),
@@ -157,18 +157,18 @@ func TestGenreServiceBuildAnnotations(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actualConceptAnnotations := service.buildAnnotations(test.contentRef)
+		actualConceptAnnotations := service.BuildAnnotations(test.contentRef)
 		assert.Equal(test.annotations, actualConceptAnnotations, fmt.Sprintf("%s: Actual concept annotations incorrect", test.name))
 	}
 }
 
 func TestSpecialReportServiceBuildAnnotations(t *testing.T) {
 	assert := assert.New(t)
-	service := SpecialReportService{"specialReports"}
+	service := defaultTaxonomyService("specialReports", "specialReports")
 	tests := []struct {
 		name        string
 		contentRef  ContentRef
-		annotations []annotation
+		annotations []Annotation
 	}{
 		{"Build concept annotation from a contentRef with 1 specialReports tag",
 			buildContentRefWithSpecialReports(1),
@@ -189,18 +189,18 @@ func TestSpecialReportServiceBuildAnnotations(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actualConceptAnnotations := service.buildAnnotations(test.contentRef)
+		actualConceptAnnotations := service.BuildAnnotations(test.contentRef)
 		assert.Equal(test.annotations, actualConceptAnnotations, fmt.Sprintf("%s: Actual concept annotations incorrect", test.name))
 	}
 }
 
 func TestAlphavilleSeriesServiceBuildAnnotations(t *testing.T) {
 	assert := assert.New(t)
-	service := AlphavilleSeriesService{"alphavilleSeries"}
+	service := defaultTaxonomyService("alphavilleSeries", "alphavilleSeries")
 	tests := []struct {
 		name        string
 		contentRef  ContentRef
-		annotations []annotation
+		annotations []Annotation
 	}{
 		{"Build concept annotation from a contentRef with 1 alphavilleSeries tag",
 			buildContentRefWithAlphavilleSeries(1),
@@ -217,18 +217,18 @@ func TestAlphavilleSeriesServiceBuildAnnotations(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actualConceptAnnotations := service.buildAnnotations(test.contentRef)
+		actualConceptAnnotations := service.BuildAnnotations(test.contentRef)
 		assert.Equal(test.annotations, actualConceptAnnotations, fmt.Sprintf("%s: Actual concept annotations incorrect.", test.name))
 	}
 }
 
 func TestOrganisationsServiceBuildAnnotations(t *testing.T) {
 	assert := assert.New(t)
-	service := OrganisationService{"on"}
+	service := defaultTaxonomyService("organisations", "on")
 	tests := []struct {
 		name        string
 		contentRef  ContentRef
-		annotations []annotation
+		annotations []Annotation
 	}{
 		{"Build concept annotation from a contentRef with 1 organisation tag",
 			buildContentRefWithOrganisations(1),
@@ -249,18 +249,18 @@ func TestOrganisationsServiceBuildAnnotations(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actualConceptAnnotations := service.buildAnnotations(test.contentRef)
+		actualConceptAnnotations := service.BuildAnnotations(test.contentRef)
 		assert.Equal(test.annotations, actualConceptAnnotations, fmt.Sprintf("%s: Actual concept annotations incorrect: ACTUAL: %v  TEST: %v ", test.name, actualConceptAnnotations, test.annotations))
 	}
 }
 
 func TestPeopleServiceBuildAnnotations(t *testing.T) {
 	assert := assert.New(t)
-	service := PeopleService{"PN"}
+	service := defaultTaxonomyService("people", "PN")
 	tests := []struct {
 		name        string
 		contentRef  ContentRef
-		annotations []annotation
+		annotations []Annotation
 	}{
 		{"Build concept annotation from a contentRef with 1 Person tag",
 			buildContentRefWithPeople(1),
@@ -281,7 +281,7 @@ func TestPeopleServiceBuildAnnotations(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actualConceptAnnotations := service.buildAnnotations(test.contentRef)
+		actualConceptAnnotations := service.BuildAnnotations(test.contentRef)
 		assert.Equal(test.annotations,
 			actualConceptAnnotations,
 			fmt.Sprintf("%s: Actual concept annotations incorrect: ACTUAL: %v  TEST: %v ",
@@ -293,11 +293,11 @@ func TestPeopleServiceBuildAnnotations(t *testing.T) {
 
 func TestAuthorServiceBuildAnnotations(t *testing.T) {
 	assert := assert.New(t)
-	service := AuthorService{"Authors"}
+	service := defaultTaxonomyService("authors", "Authors")
 	tests := []struct {
 		name        string
 		contentRef  ContentRef
-		annotations []annotation
+		annotations []Annotation
 	}{
 		{"Build concept annotation from a contentRef with 1 author tag",
 			buildContentRefWithAuthor(1),
@@ -314,18 +314,18 @@ func TestAuthorServiceBuildAnnotations(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actualConceptAnnotations := service.buildAnnotations(test.contentRef)
+		actualConceptAnnotations := service.BuildAnnotations(test.contentRef)
 		assert.Equal(test.annotations, actualConceptAnnotations, fmt.Sprintf("%s: Actual concept annotations incorrect.", test.name))
 	}
 }
 
 func TestBrandServiceBuildAnnotations(t *testing.T) {
 	assert := assert.New(t)
-	service := BrandService{HandledTaxonomy: "brands"}
+	service := defaultTaxonomyService("brands", "brands")
 	tests := []struct {
 		name        string
 		contentRef  ContentRef
-		annotations []annotation
+		annotations []Annotation
 	}{
 		{"Build concept annotation from a contentRef with 1 brand tag",
 			buildContentRefWithBrands(1),
@@ -341,11 +341,75 @@ func TestBrandServiceBuildAnnotations(t *testing.T) {
 		},
 	}
 	for _, test := range tests {
-		actualConceptAnnotations := service.buildAnnotations(test.contentRef)
+		actualConceptAnnotations := service.BuildAnnotations(test.contentRef)
 		assert.Equal(test.annotations, actualConceptAnnotations, fmt.Sprintf("%s: Actual concept annotations incorrect", test.name))
 	}
 }
 
+func TestPrimaryTermsAreMappedOnceWithTheirOwnTaxonomy(t *testing.T) {
+	assert := assert.New(t)
+	registry, err := NewTaxonomyRegistry(DefaultTaxonomyRules)
+	assert.NoError(err)
+	contentRef := ContentRef{
+		PrimarySection: Term{CanonicalName: specialReportNames[0], Taxonomy: "SpecialReports", ID: specialReportTMEIDs[0]},
+		PrimaryTheme:   Term{CanonicalName: locationNames[0], Taxonomy: "GL", ID: locationTMEIDs[0]},
+	}
+
+	annotations := []Annotation{}
+	for _, a := range registry.AnnotationsByTaxonomy(contentRef) {
+		annotations = append(annotations, a...)
+	}
+
+	assert.ElementsMatch([]Annotation{
+		{Thing: Thing{
+			ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(specialReportTMEIDs[0]).String(),
+			PrefLabel: specialReportNames[0],
+			Predicate: IsPrimarilyClassifiedBy,
+			Types:     []string{SpecialReportType},
+		}},
+		{Thing: Thing{
+			ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(locationTMEIDs[0]).String(),
+			PrefLabel: locationNames[0],
+			Predicate: About,
+			Types:     []string{LocationType},
+		}},
+	}, annotations)
+}
+
+func TestPrimaryTermIsMappedForTheOtherNamesOfItsTaxonomy(t *testing.T) {
+	service := defaultTaxonomyService("organisations", "ON")
+
+	for _, taxonomy := range []string{"ON", "Organisations", "organisations"} {
+		contentRef := ContentRef{PrimaryTheme: Term{CanonicalName: organisationNames[0], Taxonomy: taxonomy, ID: organisationTMEIDs[0]}}
+		assert.Equal(t, []Annotation{{Thing: Thing{
+			ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(organisationTMEIDs[0]).String(),
+			PrefLabel: organisationNames[0],
+			Predicate: About,
+			Types:     []string{OrganisationType},
+		}}}, service.BuildAnnotations(contentRef), taxonomy)
+	}
+}
+
+func TestPrimaryTermIsNotMappedForAnotherTaxonomy(t *testing.T) {
+	contentRef := buildContentRefWithLocationsWithPrimaryTheme(1)
+
+	for _, name := range []string{"topics", "organisations", "people"} {
+		annotations := defaultTaxonomyService(name, name).BuildAnnotations(contentRef)
+		assert.Empty(t, annotations, name)
+	}
+}
+
+// defaultTaxonomyService builds the service for the named default rule, handling the given taxonomy
+func defaultTaxonomyService(name string, handledTaxonomy string) TaxonomyService {
+	for _, rule := range DefaultTaxonomyRules {
+		if rule.Name == name {
+			rule.Taxonomy = handledTaxonomy
+			return taxonomyMappingService{rule: rule}
+		}
+	}
+	panic("no default taxonomy rule named " + name)
+}
+
 func buildContentRefWithLocations(locationCount int) ContentRef {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["locations"] = locationCount
@@ -443,352 +507,352 @@ func buildContentRefWithAuthor(authorCount int) ContentRef {
 }
 
 func buildContentRef(taxonomyAndCount map[string]int, hasPrimarySection bool, hasPrimaryTheme bool) ContentRef {
-	metadataTags := []tag{}
-	var primarySection term
-	var primaryTheme term
+	metadataTags := []Tag{}
+	var primarySection Term
+	var primaryTheme Term
 	for key, count := range taxonomyAndCount {
 		if strings.EqualFold("subjects", key) {
 			for i := 0; i < count; i++ {
-				subjectTerm := term{CanonicalName: subjectNames[i], Taxonomy: "Subjects", ID: subjectTMEIDs[i]}
-				metadataTags = append(metadataTags, tag{Term: subjectTerm, TagScore: testScore})
+				subjectTerm := Term{CanonicalName: subjectNames[i], Taxonomy: "Subjects", ID: subjectTMEIDs[i]}
+				metadataTags = append(metadataTags, Tag{Term: subjectTerm, TagScore: testScore})
 			}
 		}
 		if strings.EqualFold("sections", key) {
 			for i := 0; i < count; i++ {
-				sectionsTerm := term{CanonicalName: sectionNames[i], Taxonomy: "Sections", ID: sectionTMEIDs[i]}
-				sectionsTag := tag{Term: sectionsTerm, TagScore: testScore}
+				sectionsTerm := Term{CanonicalName: sectionNames[i], Taxonomy: "Sections", ID: sectionTMEIDs[i]}
+				sectionsTag := Tag{Term: sectionsTerm, TagScore: testScore}
 				metadataTags = append(metadataTags, sectionsTag)
 			}
 
 			if hasPrimarySection {
-				primarySection = term{CanonicalName: sectionNames[0], Taxonomy: "Sections", ID: sectionTMEIDs[0]}
+				primarySection = Term{CanonicalName: sectionNames[0], Taxonomy: "Sections", ID: sectionTMEIDs[0]}
 			}
 		}
 		if strings.EqualFold("topics", key) {
 			for i := 0; i < count; i++ {
-				topicTerm := term{CanonicalName: topicNames[i], Taxonomy: "Topics", ID: topicTMEIDs[i]}
-				topicTag := tag{Term: topicTerm, TagScore: testScore}
+				topicTerm := Term{CanonicalName: topicNames[i], Taxonomy: "Topics", ID: topicTMEIDs[i]}
+				topicTag := Tag{Term: topicTerm, TagScore: testScore}
 				metadataTags = append(metadataTags, topicTag)
 			}
 			if hasPrimaryTheme {
-				primaryTheme = term{CanonicalName: topicNames[0], Taxonomy: "Topics", ID: topicTMEIDs[0]}
+				primaryTheme = Term{CanonicalName: topicNames[0], Taxonomy: "Topics", ID: topicTMEIDs[0]}
 			}
 		}
 		if strings.EqualFold("locations", key) {
 			for i := 0; i < count; i++ {
-				locationTerm := term{CanonicalName: locationNames[i], Taxonomy: "GL", ID: locationTMEIDs[i]}
-				locationTag := tag{Term: locationTerm, TagScore: testScore}
+				locationTerm := Term{CanonicalName: locationNames[i], Taxonomy: "GL", ID: locationTMEIDs[i]}
+				locationTag := Tag{Term: locationTerm, TagScore: testScore}
 				metadataTags = append(metadataTags, locationTag)
 			}
 			if hasPrimaryTheme {
-				primaryTheme = term{CanonicalName: locationNames[0], Taxonomy: "GL", ID: locationTMEIDs[0]}
+				primaryTheme = Term{CanonicalName: locationNames[0], Taxonomy: "GL", ID: locationTMEIDs[0]}
 			}
 		}
 		if strings.EqualFold("genres", key) {
 			for i := 0; i < count; i++ {
-				genreTerm := term{CanonicalName: genreNames[i], Taxonomy: "Genres", ID: genreTMEIDs[i]}
-				metadataTags = append(metadataTags, tag{Term: genreTerm, TagScore: testScore})
+				genreTerm := Term{CanonicalName: genreNames[i], Taxonomy: "Genres", ID: genreTMEIDs[i]}
+				metadataTags = append(metadataTags, Tag{Term: genreTerm, TagScore: testScore})
 			}
 		}
 		if strings.EqualFold("brands", key) {
 			for i := 0; i < count; i++ {
-				brandTerm := term{CanonicalName: brandNames[i], Taxonomy: "Brands", ID: brandTMEIDs[i]}
-				metadataTags = append(metadataTags, tag{Term: brandTerm, TagScore: testScore})
+				brandTerm := Term{CanonicalName: brandNames[i], Taxonomy: "Brands", ID: brandTMEIDs[i]}
+				metadataTags = append(metadataTags, Tag{Term: brandTerm, TagScore: testScore})
 			}
 		}
 		if strings.EqualFold("specialReports", key) {
 			for i := 0; i < count; i++ {
-				specialReportsTerm := term{CanonicalName: specialReportNames[i], Taxonomy: "SpecialReports", ID: specialReportTMEIDs[i]}
-				sectionsTag := tag{Term: specialReportsTerm, TagScore: testScore}
+				specialReportsTerm := Term{CanonicalName: specialReportNames[i], Taxonomy: "SpecialReports", ID: specialReportTMEIDs[i]}
+				sectionsTag := Tag{Term: specialReportsTerm, TagScore: testScore}
 				metadataTags = append(metadataTags, sectionsTag)
 			}
 
 			if hasPrimarySection {
-				primarySection = term{CanonicalName: specialReportNames[0], Taxonomy: "SpecialReports", ID: specialReportTMEIDs[0]}
+				primarySection = Term{CanonicalName: specialReportNames[0], Taxonomy: "SpecialReports", ID: specialReportTMEIDs[0]}
 			}
 		}
 		if strings.EqualFold("alphavilleSeries", key) {
 			for i := 0; i < count; i++ {
-				alphavilleSeriesTerm := term{CanonicalName: alphavilleSeriesNames[i], Taxonomy: "AlphavilleSeries", ID: alphavilleSeriesTMEIDs[i]}
-				alphavilleSeriesTag := tag{Term: alphavilleSeriesTerm, TagScore: testScore}
+				alphavilleSeriesTerm := Term{CanonicalName: alphavilleSeriesNames[i], Taxonomy: "AlphavilleSeries", ID: alphavilleSeriesTMEIDs[i]}
+				alphavilleSeriesTag := Tag{Term: alphavilleSeriesTerm, TagScore: testScore}
 				metadataTags = append(metadataTags, alphavilleSeriesTag)
 			}
 		}
 		if strings.EqualFold("organisations", key) {
 			for i := 0; i < count; i++ {
-				organisationTerm := term{CanonicalName: organisationNames[i], Taxonomy: "ON", ID: organisationTMEIDs[i]}
-				organisationTag := tag{Term: organisationTerm, TagScore: testScore}
+				organisationTerm := Term{CanonicalName: organisationNames[i], Taxonomy: "ON", ID: organisationTMEIDs[i]}
+				organisationTag := Tag{Term: organisationTerm, TagScore: testScore}
 				metadataTags = append(metadataTags, organisationTag)
 			}
 			if hasPrimaryTheme {
-				primaryTheme = term{CanonicalName: organisationNames[0], Taxonomy: "Organisations", ID: organisationTMEIDs[0]}
+				primaryTheme = Term{CanonicalName: organisationNames[0], Taxonomy: "Organisations", ID: organisationTMEIDs[0]}
 			}
 		}
 
 		if strings.EqualFold("people", key) {
 			for i := 0; i < count; i++ {
-				peopleTerm := term{CanonicalName: peopleNames[i], Taxonomy: "PN", ID: peopleTMEIDs[i]}
-				peopleTag := tag{Term: peopleTerm, TagScore: testScore}
+				peopleTerm := Term{CanonicalName: peopleNames[i], Taxonomy: "PN", ID: peopleTMEIDs[i]}
+				peopleTag := Tag{Term: peopleTerm, TagScore: testScore}
 				metadataTags = append(metadataTags, peopleTag)
 			}
 			if hasPrimaryTheme {
-				primaryTheme = term{CanonicalName: peopleNames[0], Taxonomy: "People", ID: peopleTMEIDs[0]}
+				primaryTheme = Term{CanonicalName: peopleNames[0], Taxonomy: "People", ID: peopleTMEIDs[0]}
 			}
 		}
 
 		if strings.EqualFold("author", key) {
 			for i := 0; i < count; i++ {
-				authourTerm := term{CanonicalName: authorNames[i], Taxonomy: "Authors", ID: authorTMEIDs[i]}
-				authorTag := tag{Term: authourTerm, TagScore: testScore}
+				authourTerm := Term{CanonicalName: authorNames[i], Taxonomy: "Authors", ID: authorTMEIDs[i]}
+				authorTag := Tag{Term: authourTerm, TagScore: testScore}
 				metadataTags = append(metadataTags, authorTag)
 			}
 		}
 	}
-	tagHolder := tags{Tags: metadataTags}
+	tagHolder := Tags{Tags: metadataTags}
 
 	return ContentRef{TagHolder: tagHolder, PrimarySection: primarySection, PrimaryTheme: primaryTheme}
 }
 
-func buildConceptAnnotationsWithLocations(locationCount int) []annotation {
+func buildConceptAnnotationsWithLocations(locationCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["locations"] = locationCount
 	return buildConceptAnnotations(taxonomyAndCount, false, false)
 }
 
-func buildConceptAnnotationsWithLocationsWithPrimaryTheme(locationCount int) []annotation {
+func buildConceptAnnotationsWithLocationsWithPrimaryTheme(locationCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["locations"] = locationCount
 	return buildConceptAnnotations(taxonomyAndCount, false, true)
 }
 
-func buildConceptAnnotationsWithTopics(topicCount int) []annotation {
+func buildConceptAnnotationsWithTopics(topicCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["topics"] = topicCount
 	return buildConceptAnnotations(taxonomyAndCount, false, false)
 }
 
-func buildConceptAnnotationsWithTopicsWithPrimaryTheme(topicsCount int) []annotation {
+func buildConceptAnnotationsWithTopicsWithPrimaryTheme(topicsCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["topics"] = topicsCount
 	return buildConceptAnnotations(taxonomyAndCount, false, true)
 }
 
-func buildConceptAnnotationsWithSections(sectionCount int) []annotation {
+func buildConceptAnnotationsWithSections(sectionCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["sections"] = sectionCount
 	return buildConceptAnnotations(taxonomyAndCount, false, false)
 }
 
-func buildConceptAnnotationsWithPrimarySection(taxonomyName string, sectionCount int) []annotation {
+func buildConceptAnnotationsWithPrimarySection(taxonomyName string, sectionCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount[taxonomyName] = sectionCount
 	return buildConceptAnnotations(taxonomyAndCount, true, false)
 }
 
-func buildConceptAnnotationsWithSubjects(subjectCount int) []annotation {
+func buildConceptAnnotationsWithSubjects(subjectCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["subjects"] = subjectCount
 	return buildConceptAnnotations(taxonomyAndCount, false, false)
 }
 
-func buildConceptAnnotationsWithGenres(genreCount int) []annotation {
+func buildConceptAnnotationsWithGenres(genreCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["genres"] = genreCount
 	return buildConceptAnnotations(taxonomyAndCount, false, false)
 }
 
-func buildConceptAnnotationsWithBrands(count int) []annotation {
+func buildConceptAnnotationsWithBrands(count int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["brands"] = count
 	return buildConceptAnnotations(taxonomyAndCount, false, false)
 }
 
-func buildConceptAnnotationsWithSpecialReports(reportCount int) []annotation {
+func buildConceptAnnotationsWithSpecialReports(reportCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["specialReports"] = reportCount
 	return buildConceptAnnotations(taxonomyAndCount, false, false)
 }
 
-func buildConceptAnnotationsWithAlphavilleSeries(seriesCount int) []annotation {
+func buildConceptAnnotationsWithAlphavilleSeries(seriesCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["alphavilleSeries"] = seriesCount
 	return buildConceptAnnotations(taxonomyAndCount, false, false)
 }
 
-func buildConceptAnnotationsWithOrganisations(orgsCount int) []annotation {
+func buildConceptAnnotationsWithOrganisations(orgsCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["organisations"] = orgsCount
 	return buildConceptAnnotations(taxonomyAndCount, false, false)
 }
 
-func buildConceptAnnotationsWithOrganisationsWithPrimaryTheme(orgsCount int) []annotation {
+func buildConceptAnnotationsWithOrganisationsWithPrimaryTheme(orgsCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["organisations"] = orgsCount
 	return buildConceptAnnotations(taxonomyAndCount, false, true)
 }
 
-func buildConceptAnnotationsWithPeople(peopleCount int) []annotation {
+func buildConceptAnnotationsWithPeople(peopleCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["people"] = peopleCount
 	return buildConceptAnnotations(taxonomyAndCount, false, false)
 }
 
-func buildConceptAnnotationsWithPeopleWithPrimaryTheme(peopleCount int) []annotation {
+func buildConceptAnnotationsWithPeopleWithPrimaryTheme(peopleCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["people"] = peopleCount
 	return buildConceptAnnotations(taxonomyAndCount, false, true)
 }
 
-func buildConceptAnnotationsWithAuthor(authorCount int) []annotation {
+func buildConceptAnnotationsWithAuthor(authorCount int) []Annotation {
 	taxonomyAndCount := make(map[string]int)
 	taxonomyAndCount["author"] = authorCount
 	return buildConceptAnnotations(taxonomyAndCount, false, false)
 }
 
-func buildConceptAnnotations(taxonomyAndCount map[string]int, hasPrimarySection bool, hasPrimaryTheme bool) []annotation {
-	annotations := []annotation{}
+func buildConceptAnnotations(taxonomyAndCount map[string]int, hasPrimarySection bool, hasPrimaryTheme bool) []Annotation {
+	annotations := []Annotation{}
 
-	relevance := score{ScoringSystem: relevanceURI, Value: 0.65}
-	confidence := score{ScoringSystem: confidenceURI, Value: 0.93}
-	metadataProvenance := provenance{Scores: []score{relevance, confidence}}
+	relevance := Score{ScoringSystem: RelevanceScoringSystem, Value: 0.65}
+	confidence := Score{ScoringSystem: ConfidenceScoringSystem, Value: 0.93}
+	metadataProvenance := Provenance{Scores: []Score{relevance, confidence}}
 	for key, count := range taxonomyAndCount {
 		if strings.EqualFold("subjects", key) {
 			for i := 0; i < count; i++ {
-				thing := thing{
+				thing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(subjectTMEIDs[i]).String(),
 					PrefLabel: subjectNames[i],
-					Predicate: classification,
-					Types:     []string{subjectURI},
+					Predicate: IsClassifiedBy,
+					Types:     []string{SubjectType},
 				}
-				subjectAnnotation := annotation{Thing: thing, Provenance: []provenance{metadataProvenance}}
+				subjectAnnotation := Annotation{Thing: thing, Provenance: []Provenance{metadataProvenance}}
 				annotations = append(annotations, subjectAnnotation)
 			}
 		}
 		if strings.EqualFold("sections", key) {
 			for i := 0; i < count; i++ {
-				thing := thing{
+				thing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(sectionTMEIDs[i]).String(),
 					PrefLabel: sectionNames[i],
-					Predicate: classification,
-					Types:     []string{sectionURI},
+					Predicate: IsClassifiedBy,
+					Types:     []string{SectionType},
 				}
-				sectionAnnotation := annotation{Thing: thing, Provenance: []provenance{metadataProvenance}}
+				sectionAnnotation := Annotation{Thing: thing, Provenance: []Provenance{metadataProvenance}}
 				annotations = append(annotations, sectionAnnotation)
 			}
 
 			if count > 0 && hasPrimarySection {
-				thing := thing{
+				thing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(sectionTMEIDs[0]).String(),
 					PrefLabel: sectionNames[0],
-					Predicate: primaryClassification,
-					Types:     []string{sectionURI},
+					Predicate: IsPrimarilyClassifiedBy,
+					Types:     []string{SectionType},
 				}
-				sectionAnnotation := annotation{Thing: thing}
+				sectionAnnotation := Annotation{Thing: thing}
 				annotations = append(annotations, sectionAnnotation)
 			}
 		}
 		if strings.EqualFold("topics", key) {
 			for i := 0; i < count; i++ {
-				oneThing := thing{
+				oneThing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(topicTMEIDs[i]).String(),
 					PrefLabel: topicNames[i],
-					Predicate: conceptMajorMentions,
-					Types:     []string{topicURI},
+					Predicate: MajorMentions,
+					Types:     []string{TopicType},
 				}
-				topicAnnotation := annotation{Thing: oneThing, Provenance: []provenance{metadataProvenance}}
+				topicAnnotation := Annotation{Thing: oneThing, Provenance: []Provenance{metadataProvenance}}
 
 				annotations = append(annotations, topicAnnotation)
 			}
 			if count > 0 && hasPrimaryTheme {
-				thing := thing{
+				thing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(topicTMEIDs[0]).String(),
 					PrefLabel: topicNames[0],
-					Predicate: about,
-					Types:     []string{topicURI},
+					Predicate: About,
+					Types:     []string{TopicType},
 				}
-				topicAnnotation := annotation{Thing: thing}
+				topicAnnotation := Annotation{Thing: thing}
 				annotations = append(annotations, topicAnnotation)
 			}
 		}
 		if strings.EqualFold("locations", key) {
 			for i := 0; i < count; i++ {
-				oneThing := thing{
+				oneThing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(locationTMEIDs[i]).String(),
 					PrefLabel: locationNames[i],
-					Predicate: conceptMajorMentions,
-					Types:     []string{locationURI},
+					Predicate: MajorMentions,
+					Types:     []string{LocationType},
 				}
-				locationAnnotation := annotation{Thing: oneThing, Provenance: []provenance{metadataProvenance}}
+				locationAnnotation := Annotation{Thing: oneThing, Provenance: []Provenance{metadataProvenance}}
 
 				annotations = append(annotations, locationAnnotation)
 			}
 			if count > 0 && hasPrimaryTheme {
-				thing := thing{
+				thing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(locationTMEIDs[0]).String(),
 					PrefLabel: locationNames[0],
-					Predicate: about,
-					Types:     []string{locationURI},
+					Predicate: About,
+					Types:     []string{LocationType},
 				}
-				locationAnnotation := annotation{Thing: thing}
+				locationAnnotation := Annotation{Thing: thing}
 				annotations = append(annotations, locationAnnotation)
 			}
 		}
 		if strings.EqualFold("genres", key) {
 			for i := 0; i < count; i++ {
-				thing := thing{
+				thing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(genreTMEIDs[i]).String(),
 					PrefLabel: genreNames[i],
-					Predicate: classification,
-					Types:     []string{genreURI},
+					Predicate: IsClassifiedBy,
+					Types:     []string{GenreType},
 				}
-				genreAnnotation := annotation{Thing: thing, Provenance: []provenance{metadataProvenance}}
+				genreAnnotation := Annotation{Thing: thing, Provenance: []Provenance{metadataProvenance}}
 				annotations = append(annotations, genreAnnotation)
 			}
 		}
 		if strings.EqualFold("brands", key) {
 			for i := 0; i < count; i++ {
-				thing := thing{
+				thing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(brandTMEIDs[i]).String(),
 					PrefLabel: brandNames[i],
-					Predicate: classification,
-					Types:     []string{brandURI},
+					Predicate: IsClassifiedBy,
+					Types:     []string{BrandType},
 				}
-				brandAnnotation := annotation{Thing: thing, Provenance: []provenance{metadataProvenance}}
+				brandAnnotation := Annotation{Thing: thing, Provenance: []Provenance{metadataProvenance}}
 				annotations = append(annotations, brandAnnotation)
 			}
 		}
 		if strings.EqualFold("specialReports", key) {
 			for i := 0; i < count; i++ {
-				thing := thing{
+				thing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(specialReportTMEIDs[i]).String(),
 					PrefLabel: specialReportNames[i],
-					Predicate: classification,
-					Types:     []string{specialReportURI},
+					Predicate: IsClassifiedBy,
+					Types:     []string{SpecialReportType},
 				}
-				specialReportAnnotation := annotation{Thing: thing, Provenance: []provenance{metadataProvenance}}
+				specialReportAnnotation := Annotation{Thing: thing, Provenance: []Provenance{metadataProvenance}}
 				annotations = append(annotations, specialReportAnnotation)
 			}
 
 			if count > 0 && hasPrimarySection {
-				thing := thing{
+				thing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(specialReportTMEIDs[0]).String(),
 					PrefLabel: specialReportNames[0],
-					Predicate: primaryClassification,
-					Types:     []string{specialReportURI},
+					Predicate: IsPrimarilyClassifiedBy,
+					Types:     []string{SpecialReportType},
 				}
-				specialReportAnnotation := annotation{Thing: thing}
+				specialReportAnnotation := Annotation{Thing: thing}
 				annotations = append(annotations, specialReportAnnotation)
 			}
 		}
 		if strings.EqualFold("alphavilleSeries", key) {
 			for i := 0; i < count; i++ {
-				oneThing := thing{
+				oneThing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(alphavilleSeriesTMEIDs[i]).String(),
 					PrefLabel: alphavilleSeriesNames[i],
-					Predicate: classification,
-					Types:     []string{alphavilleSeriesURI},
+					Predicate: IsClassifiedBy,
+					Types:     []string{AlphavilleSeriesType},
 				}
-				alphavilleSeriesAnnotation := annotation{Thing: oneThing, Provenance: []provenance{metadataProvenance}}
+				alphavilleSeriesAnnotation := Annotation{Thing: oneThing, Provenance: []Provenance{metadataProvenance}}
 
 				annotations = append(annotations, alphavilleSeriesAnnotation)
 			}
@@ -796,25 +860,25 @@ func buildConceptAnnotations(taxonomyAndCount map[string]int, hasPrimarySection
 		if strings.EqualFold("organisations", key) {
 
 			for i := 0; i < count; i++ {
-				oneThing := thing{
+				oneThing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(organisationTMEIDs[i]).String(),
 					PrefLabel: organisationNames[i],
-					Predicate: conceptMajorMentions,
-					Types:     []string{organisationURI},
+					Predicate: MajorMentions,
+					Types:     []string{OrganisationType},
 				}
-				organisationAnnotation := annotation{Thing: oneThing, Provenance: []provenance{metadataProvenance}}
+				organisationAnnotation := Annotation{Thing: oneThing, Provenance: []Provenance{metadataProvenance}}
 
 				annotations = append(annotations, organisationAnnotation)
 
 			}
 			if count > 0 && hasPrimaryTheme {
-				thing := thing{
+				thing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(organisationTMEIDs[0]).String(),
 					PrefLabel: organisationNames[0],
-					Predicate: about,
-					Types:     []string{organisationURI},
+					Predicate: About,
+					Types:     []string{OrganisationType},
 				}
-				organisationAnnotation := annotation{Thing: thing}
+				organisationAnnotation := Annotation{Thing: thing}
 				annotations = append(annotations, organisationAnnotation)
 			}
 
@@ -822,37 +886,37 @@ func buildConceptAnnotations(taxonomyAndCount map[string]int, hasPrimarySection
 		if strings.EqualFold("people", key) {
 
 			for i := 0; i < count; i++ {
-				oneThing := thing{
+				oneThing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(peopleTMEIDs[i]).String(),
 					PrefLabel: peopleNames[i],
-					Predicate: conceptMajorMentions,
-					Types:     []string{personURI},
+					Predicate: MajorMentions,
+					Types:     []string{PersonType},
 				}
-				peopleAnnotation := annotation{Thing: oneThing, Provenance: []provenance{metadataProvenance}}
+				peopleAnnotation := Annotation{Thing: oneThing, Provenance: []Provenance{metadataProvenance}}
 				annotations = append(annotations, peopleAnnotation)
 
 			}
 
 			if count > 0 && hasPrimaryTheme {
-				thing := thing{
+				thing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(peopleTMEIDs[0]).String(),
 					PrefLabel: peopleNames[0],
-					Predicate: about,
-					Types:     []string{personURI},
+					Predicate: About,
+					Types:     []string{PersonType},
 				}
-				peopleAnnotation := annotation{Thing: thing}
+				peopleAnnotation := Annotation{Thing: thing}
 				annotations = append(annotations, peopleAnnotation)
 			}
 		}
 		if strings.EqualFold("author", key) {
 			for i := 0; i < count; i++ {
-				oneThing := thing{
+				oneThing := Thing{
 					ID:        "http://api.ft.com/things/" + uuidutils.NewV3UUID(authorTMEIDs[i]).String(),
 					PrefLabel: authorNames[i],
-					Predicate: hasAuthor,
-					Types:     []string{authorURI},
+					Predicate: HasAuthor,
+					Types:     []string{AuthorType},
 				}
-				authorAnnotation := annotation{Thing: oneThing, Provenance: []provenance{metadataProvenance}}
+				authorAnnotation := Annotation{Thing: oneThing, Provenance: []Provenance{metadataProvenance}}
 
 				annotations = append(annotations, authorAnnotation)
 			}
@@ -862,7 +926,7 @@ func buildConceptAnnotations(taxonomyAndCount map[string]int, hasPrimarySection
 	return annotations
 }
 
-var testScore = tagScore{Confidence: 93, Relevance: 65}
+var testScore = TagScore{Confidence: 93, Relevance: 65}
 var subjectNames = [...]string{"Mining Industry", "Oil Extraction Subsidies"}
 var subjectTMEIDs = [...]string{"Mjk=-U2VjdGlvbnM=", "Nw==-R2VucmVz"}
 var sectionNames = [...]string{"Companies", "Emerging Markets"}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/Shopify/sarama"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMapper(producer kafka.Producer) *Mapper {
+	return NewMapper(MapperDependencies{Producer: producer, Config: newDefaultConfigStore()})
+}
+
+// setTestConfig applies a copy of the mapping configuration of the mapper changed by configure
+func setTestConfig(mapper *Mapper, configure func(config *mappingConfig)) {
+	config := *mapper.config.current()
+	configure(&config)
+	mapper.config.value.Store(&config)
+}
+
+func TestNewMapper__Defaults(t *testing.T) {
+	mapper := newTestMapper(&mockProducer{})
+
+	assert.Nil(t, mapper.deadLetter)
+	assert.Nil(t, mapper.duplicates)
+	assert.NotNil(t, mapper.partitionKey)
+	assert.Equal(t, defaultProduceAttempts, mapper.retry.maxAttempts)
+	assert.Equal(t, defaultProduceRetryBackoff, mapper.retry.initialBackoff)
+	assert.NotEmpty(t, mapper.newID())
+	assert.NotEqual(t, mapper.newID(), mapper.newID())
+}
+
+func TestMapper__UsesTheInjectedClockAndIDs(t *testing.T) {
+	producer := &mockProducer{}
+	mapper := NewMapper(MapperDependencies{
+		Producer: producer,
+		Config:   newDefaultConfigStore(),
+		Clock:    func() time.Time { return time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC) },
+		NewID:    func() string { return "e2c7f8a2-92c0-4a1c-9a5d-0d5e2b7c8f01" },
+	})
+
+	require.NoError(t, mapper.Map(context.Background(), publishEventMessage(uuid.New(), "tid_test")))
+
+	require.Len(t, producer.messages, 1)
+	assert.Equal(t, "e2c7f8a2-92c0-4a1c-9a5d-0d5e2b7c8f01", producer.messages[0].Headers["Message-Id"])
+	assert.Equal(t, "2020-06-01T12:30:00.000Z", producer.messages[0].Headers["Message-Timestamp"])
+	assert.Equal(t, "tid_test", producer.messages[0].Headers["X-Request-Id"])
+}
+
+func TestMapper__MappersHaveTheirOwnConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		whitelist    string
+		expectedSent int
+	}{
+		{"methode", defaultWhitelist, 1},
+		{"pac", "http://cmdb\\.ft\\.com/systems/pac", 0},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			producer := &mockProducer{}
+			mapper := newTestMapper(producer)
+			settings := defaultSettings
+			settings.Whitelist = test.whitelist
+			config, err := newMappingConfig(settings, startupConfigSource)
+			require.NoError(t, err)
+			mapper.config = newConfigStore(config)
+
+			for i := 0; i < 10; i++ {
+				require.NoError(t, mapper.Map(context.Background(), publishEventMessage(uuid.New(), "tid_test")))
+			}
+
+			assert.Len(t, producer.messages, 10*test.expectedSent)
+		})
+	}
+}
+
+func TestMapper__CancelledContextStopsTheProduceRetries(t *testing.T) {
+	var sleeps []time.Duration
+	producer := &mockProducer{err: sarama.ErrLeaderNotAvailable}
+	dlq := &mockProducer{}
+	mapper := newTestMapper(producer)
+	mapper.retry = testRetryPolicy(5, &sleeps)
+	mapper.deadLetter = dlq
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := mapper.Map(ctx, publishEventMessage(uuid.New(), "tid_test"))
+
+	assert.Equal(t, sarama.ErrLeaderNotAvailable, err)
+	assert.Len(t, producer.messages, 1)
+	assert.Empty(t, sleeps)
+	require.Len(t, dlq.messages, 1)
+	assert.Equal(t, produceStage, dlq.messages[0].Headers[failureStageHeader])
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"mime"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+)
+
+// jsonMetadataContentType is the Content-Type of the publish events whose metadata is JSON instead of V1 XML
+const jsonMetadataContentType = "application/vnd.ft-upp-metadata+json"
+
+// metadataDecoder decodes the metadata of a publish event, once base64 decoded, into the ContentRef the taxonomy services map
+type metadataDecoder interface {
+	// format names the metadata format in the logs
+	format() string
+	// stage is the failure stage reported when the metadata cannot be decoded
+	stage() string
+	decode(metadata []byte) (mapper.ContentRef, error)
+}
+
+// metadataDecoders are the decoders of the metadata formats other than V1 XML, by Content-Type
+var metadataDecoders = map[string]metadataDecoder{
+	jsonMetadataContentType: jsonMetadataDecoder{},
+}
+
+// metadataDecoderFor returns the decoder for the Content-Type of a message. Messages of any other Content-Type,
+// usually application/json for the publish event itself, have V1 XML metadata.
+func metadataDecoderFor(contentType string) metadataDecoder {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if decoder, found := metadataDecoders[mediaType]; found {
+			return decoder
+		}
+	}
+	return xmlMetadataDecoder{}
+}
+
+// conceptAnnotationsContentType is the Content-Type of the concept annotations produced for a message:
+// the Content-Type of the publish event, unless it names the format of its metadata
+func conceptAnnotationsContentType(publishEventContentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(publishEventContentType); err == nil {
+		if _, found := metadataDecoders[mediaType]; found {
+			return "application/json"
+		}
+	}
+	return publishEventContentType
+}
+
+type xmlMetadataDecoder struct{}
+
+func (xmlMetadataDecoder) format() string {
+	return "XML"
+}
+
+func (xmlMetadataDecoder) stage() string {
+	return xmlStage
+}
+
+func (xmlMetadataDecoder) decode(metadata []byte) (mapper.ContentRef, error) {
+	return mapper.ParseV1Metadata(metadata)
+}
+
+// jsonMetadata is the JSON metadata format: the tags of the content, with their term and scores, and its primary terms
+type jsonMetadata struct {
+	Tags           []mapper.Tag `json:"tags"`
+	PrimarySection mapper.Term  `json:"primarySection"`
+	PrimaryTheme   mapper.Term  `json:"primaryTheme"`
+}
+
+type jsonMetadataDecoder struct{}
+
+func (jsonMetadataDecoder) format() string {
+	return "JSON"
+}
+
+func (jsonMetadataDecoder) stage() string {
+	return jsonMetadataStage
+}
+
+func (jsonMetadataDecoder) decode(metadata []byte) (mapper.ContentRef, error) {
+	var m jsonMetadata
+	if err := json.Unmarshal(metadata, &m); err != nil {
+		return mapper.ContentRef{}, err
+	}
+	return mapper.ContentRef{TagHolder: mapper.Tags{Tags: m.Tags}, PrimarySection: m.PrimarySection, PrimaryTheme: m.PrimaryTheme}, nil
+}
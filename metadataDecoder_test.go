@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJSONMetadata = `{
+	"tags": [
+		{"term": {"id": "NjM=-U3ViamVjdHM=", "canonicalName": "Economic News", "taxonomy": "Subjects"}, "score": {"confidence": 90, "relevance": 80}},
+		{"term": {"id": "TnN0ZWluX0dMX1VT-R0w=", "canonicalName": "United States of America", "taxonomy": "GL"}, "score": {"confidence": 70, "relevance": 60}}
+	],
+	"primarySection": {"id": "MTA2-U2VjdGlvbnM=", "canonicalName": "Emerging Markets", "taxonomy": "Sections"}
+}`
+
+func TestMetadataDecoderFor(t *testing.T) {
+	assert.IsType(t, jsonMetadataDecoder{}, metadataDecoderFor(jsonMetadataContentType))
+	assert.IsType(t, jsonMetadataDecoder{}, metadataDecoderFor(jsonMetadataContentType+"; charset=utf-8"))
+	assert.IsType(t, xmlMetadataDecoder{}, metadataDecoderFor("application/json"))
+	assert.IsType(t, xmlMetadataDecoder{}, metadataDecoderFor(""))
+}
+
+func TestJSONMetadataDecoder(t *testing.T) {
+	metadata, err := jsonMetadataDecoder{}.decode([]byte(testJSONMetadata))
+
+	require.NoError(t, err)
+	assert.Equal(t, mapper.ContentRef{
+		TagHolder: mapper.Tags{Tags: []mapper.Tag{
+			{Term: mapper.Term{ID: "NjM=-U3ViamVjdHM=", CanonicalName: "Economic News", Taxonomy: "Subjects"}, TagScore: mapper.TagScore{Confidence: 90, Relevance: 80}},
+			{Term: mapper.Term{ID: "TnN0ZWluX0dMX1VT-R0w=", CanonicalName: "United States of America", Taxonomy: "GL"}, TagScore: mapper.TagScore{Confidence: 70, Relevance: 60}},
+		}},
+		PrimarySection: mapper.Term{ID: "MTA2-U2VjdGlvbnM=", CanonicalName: "Emerging Markets", Taxonomy: "Sections"},
+	}, metadata)
+
+	_, err = jsonMetadataDecoder{}.decode([]byte(`<ContentRef/>`))
+	assert.Error(t, err)
+}
+
+func TestHandleMessage__JSONMetadata(t *testing.T) {
+	producer := &mockProducer{}
+	m := newTestMapper(producer)
+
+	msg := publishEventMessage(uuid.New(), "tid_test")
+	msg.Headers["Content-Type"] = jsonMetadataContentType
+	msg.Body = `{"uuid":"` + uuid.New() + `","value":"` + base64.StdEncoding.EncodeToString([]byte(testJSONMetadata)) + `"}`
+
+	require.NoError(t, m.Map(context.Background(), msg))
+	require.Len(t, producer.messages, 1)
+	assert.Equal(t, "application/json", producer.messages[0].Headers["Content-Type"])
+
+	var conceptAnnotations mapper.ConceptAnnotations
+	require.NoError(t, json.Unmarshal([]byte(producer.messages[0].Body), &conceptAnnotations))
+	assert.Len(t, conceptAnnotations.Annotations, 3)
+	assert.Contains(t, conceptAnnotations.Annotations, mapper.Annotation{
+		Thing: mapper.Thing{
+			ID:        mapper.GenerateID("NjM=-U3ViamVjdHM="),
+			PrefLabel: "Economic News",
+			Predicate: mapper.IsClassifiedBy,
+			Types:     []string{mapper.SubjectType},
+		},
+		Provenance: []mapper.Provenance{{Scores: []mapper.Score{{ScoringSystem: mapper.RelevanceScoringSystem, Value: 0.8}, {ScoringSystem: mapper.ConfidenceScoringSystem, Value: 0.9}}}},
+	})
+}
+
+func TestHandleMessage__InvalidJSONMetadataIsSentToDeadLetter(t *testing.T) {
+	dlq := &mockProducer{}
+	m := newTestMapper(nil)
+	m.deadLetter = dlq
+
+	msg := publishEventMessage(uuid.New(), "tid_test")
+	msg.Headers["Content-Type"] = jsonMetadataContentType
+
+	assert.Error(t, m.Map(context.Background(), msg))
+	require.Len(t, dlq.messages, 1)
+	assert.Equal(t, jsonMetadataStage, dlq.messages[0].Headers[failureStageHeader])
+}
+
+func TestMapHandler__RawJSONMetadata(t *testing.T) {
+	testUUID := uuid.New()
+	req := httptest.NewRequest("POST", "http://example.com/map?uuid="+testUUID, strings.NewReader(testJSONMetadata))
+	req.Header.Set("Content-Type", jsonMetadataContentType)
+	w := httptest.NewRecorder()
+
+	newTestMapper(nil).mapHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var conceptAnnotations mapper.ConceptAnnotations
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &conceptAnnotations))
+	assert.Equal(t, testUUID, conceptAnnotations.UUID)
+	assert.Len(t, conceptAnnotations.Annotations, 3)
+}
+
+func TestMapHandler__JSONMetadataPublishEvent(t *testing.T) {
+	testUUID := uuid.New()
+	body := `{"uuid":"` + testUUID + `","value":"` + base64.StdEncoding.EncodeToString([]byte(testJSONMetadata)) + `"}`
+	req := httptest.NewRequest("POST", "http://example.com/map?contentType="+url.QueryEscape(jsonMetadataContentType), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	newTestMapper(nil).mapHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var conceptAnnotations mapper.ConceptAnnotations
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &conceptAnnotations))
+	assert.Equal(t, testUUID, conceptAnnotations.UUID)
+	assert.Len(t, conceptAnnotations.Annotations, 3)
+}
+
+func TestMapPublishEvents__JSONMetadata(t *testing.T) {
+	testUUID := uuid.New()
+	events := `{"uuid":"` + testUUID + `","value":"` + base64.StdEncoding.EncodeToString([]byte(testJSONMetadata)) + `"}` + "\n"
+
+	f, err := ioutil.TempFile("", "events-*.jsonl")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(events)
+	require.NoError(t, err)
+	f.Close()
+
+	out := &bytes.Buffer{}
+	total, failed, err := mapPublishEvents(newDefaultConfigStore(), f.Name(), out, "http://cmdb.ft.com/systems/methode-web-pub", jsonMetadataContentType)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, 0, failed)
+
+	conceptAnnotations := readConceptAnnotationLines(t, out)
+	require.Len(t, conceptAnnotations, 1)
+	assert.Equal(t, testUUID, conceptAnnotations[0].UUID)
+	assert.Len(t, conceptAnnotations[0].Annotations, 3)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "annotations_mapper"
+
+var (
+	messagesConsumed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_consumed_total",
+		Help:      "Number of metadata publish events consumed from the queue.",
+	})
+	messagesSkipped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_skipped_total",
+		Help:      "Number of metadata publish events skipped because their Origin-System-Id does not match the whitelist.",
+	})
+	messagesFiltered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_filtered_total",
+		Help:      "Number of metadata publish events skipped by the filter rules, by the rule that skipped them.",
+	}, []string{"rule"})
+	messagesFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_failed_total",
+		Help:      "Number of metadata publish events that could not be mapped, by the stage that failed.",
+	}, []string{"stage"})
+	messagesMapped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_mapped_total",
+		Help:      "Number of metadata publish events successfully mapped and written to the queue.",
+	})
+	messagesUnchanged = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_unchanged_total",
+		Help:      "Number of metadata publish events not written to the queue because their concept annotations were produced unchanged within the duplicate window.",
+	})
+	schemaViolations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "schema_violations_total",
+		Help:      "Number of metadata publish events whose concept annotations did not conform to the JSON Schema of the output format.",
+	})
+	annotationsProduced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "annotations_produced_total",
+		Help:      "Number of annotations written to the queue, by taxonomy and predicate.",
+	}, []string{"taxonomy", "predicate"})
+	produceRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "produce_retries_total",
+		Help:      "Number of times writing concept annotations to the queue was retried after a transient failure.",
+	})
+	produceAttempts = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "produce_attempts",
+		Help:      "Number of attempts taken to write the concept annotations of a message to the queue.",
+		Buckets:   prometheus.LinearBuckets(1, 1, 10),
+	})
+	configReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "config_reloads_total",
+		Help:      "Number of mapping configurations applied or failed to apply, by source and result.",
+	}, []string{"source", "result"})
+	consumeToProduceLag = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "consume_to_produce_lag_seconds",
+		Help:      "Time from consuming a metadata publish event to writing its concept annotations to the queue.",
+		Buckets:   prometheus.DefBuckets,
+	})
+	publishToProduceLag = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "publish_to_produce_lag_seconds",
+		Help:      "Time from the Message-Timestamp of a metadata publish event to writing its concept annotations to the queue.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+	})
+	handleMessageDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "handle_message_duration_seconds",
+		Help:      "Time taken to handle a metadata publish event, from consuming it to writing the concept annotations to the queue.",
+		Buckets:   prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	prometheus.MustRegister(messagesConsumed, messagesSkipped, messagesFiltered, messagesFailed, messagesMapped, messagesUnchanged, schemaViolations, annotationsProduced, produceRetries, produceAttempts, configReloads, consumeToProduceLag, publishToProduceLag, handleMessageDuration)
+}
+
+// countProducedAnnotations counts the annotations written to the queue, attributing each of them
+// to the taxonomy that mapped the concept with the predicate that was kept after consolidation
+func countProducedAnnotations(annotationsByTaxonomy map[string][]mapper.Annotation, produced []mapper.Annotation) {
+	taxonomies := make(map[string]string)
+	for taxonomy, annotations := range annotationsByTaxonomy {
+		for _, a := range annotations {
+			taxonomies[a.Thing.ID+" "+a.Thing.Predicate] = taxonomy
+		}
+	}
+	for _, a := range produced {
+		annotationsProduced.WithLabelValues(taxonomies[a.Thing.ID+" "+a.Thing.Predicate], a.Thing.Predicate).Inc()
+	}
+}
+
+// observeLag measures how long the concept annotations produced took since the metadata publish event was consumed, and since it was published
+// when its Message-Timestamp can be parsed. It returns the lags in milliseconds, as the fields of the log entry of the mapped message.
+func observeLag(publishEventHeaders map[string]string, consumedAt time.Time, producedAt time.Time) map[string]interface{} {
+	consumeLag := producedAt.Sub(consumedAt)
+	consumeToProduceLag.Observe(nonNegativeSeconds(consumeLag))
+	fields := map[string]interface{}{"consumeToProduceLagMs": consumeLag.Milliseconds()}
+
+	publishedAt, err := time.Parse(messageTimestampDateFormat, publishEventHeaders["Message-Timestamp"])
+	if err != nil {
+		return fields
+	}
+	publishLag := producedAt.Sub(publishedAt)
+	publishToProduceLag.Observe(nonNegativeSeconds(publishLag))
+	fields["publishToProduceLagMs"] = publishLag.Milliseconds()
+	return fields
+}
+
+// nonNegativeSeconds observes the lags made negative by clock skew between the publisher and the mapper as no lag
+func nonNegativeSeconds(d time.Duration) float64 {
+	if d < 0 {
+		return 0
+	}
+	return d.Seconds()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	"github.com/Financial-Times/go-logger"
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/pborman/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleMessage__Metrics(t *testing.T) {
+	producer := &mockProducer{}
+	m := newTestMapper(producer)
+
+	consumed := testutil.ToFloat64(messagesConsumed)
+	skipped := testutil.ToFloat64(messagesSkipped)
+	jsonFailures := testutil.ToFloat64(messagesFailed.WithLabelValues(jsonStage))
+	mapped := testutil.ToFloat64(messagesMapped)
+	subjects := testutil.ToFloat64(annotationsProduced.WithLabelValues("subjects", mapper.IsClassifiedBy))
+
+	skippedMsg := kafka.FTMessage{Headers: map[string]string{"Origin-System-Id": "http://cmdb.ft.com/systems/pac"}}
+	assert.NoError(t, m.Map(context.Background(), skippedMsg))
+
+	invalidMsg := kafka.FTMessage{Headers: map[string]string{"Origin-System-Id": "http://cmdb.ft.com/systems/methode-web-pub"}}
+	assert.Error(t, m.Map(context.Background(), invalidMsg))
+
+	validMsg := kafka.FTMessage{
+		Headers: map[string]string{"Origin-System-Id": "http://cmdb.ft.com/systems/methode-web-pub"},
+		Body:    `{"uuid":"` + uuid.New() + `","value":"` + validUTF8Metadata + `"}`,
+	}
+	require.NoError(t, m.Map(context.Background(), validMsg))
+	require.Len(t, producer.messages, 1)
+
+	assert.Equal(t, consumed+3, testutil.ToFloat64(messagesConsumed))
+	assert.Equal(t, skipped+1, testutil.ToFloat64(messagesSkipped))
+	assert.Equal(t, jsonFailures+1, testutil.ToFloat64(messagesFailed.WithLabelValues(jsonStage)))
+	assert.Equal(t, mapped+1, testutil.ToFloat64(messagesMapped))
+	assert.Equal(t, subjects+3, testutil.ToFloat64(annotationsProduced.WithLabelValues("subjects", mapper.IsClassifiedBy)))
+}
+
+func TestObserveLag(t *testing.T) {
+	producedAt := time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	fields := observeLag(map[string]string{"Message-Timestamp": "2020-06-01T12:29:00.500Z"}, producedAt.Add(-250*time.Millisecond), producedAt)
+	assert.Equal(t, map[string]interface{}{"consumeToProduceLagMs": int64(250), "publishToProduceLagMs": int64(59500)}, fields)
+
+	fields = observeLag(map[string]string{"Message-Timestamp": "yesterday"}, producedAt, producedAt)
+	assert.Equal(t, map[string]interface{}{"consumeToProduceLagMs": int64(0)}, fields)
+
+	fields = observeLag(map[string]string{}, producedAt, producedAt)
+	assert.NotContains(t, fields, "publishToProduceLagMs")
+}
+
+func TestMap__LogsAndKeepsTheSourceTimestamp(t *testing.T) {
+	producer := &mockProducer{}
+	consumedAt := time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC)
+	calls := 0
+	m := NewMapper(MapperDependencies{
+		Producer: producer,
+		Config:   newDefaultConfigStore(),
+		Clock: func() time.Time {
+			calls++
+			return consumedAt.Add(time.Duration(calls-1) * time.Second)
+		},
+	})
+	msg := publishEventMessage(uuid.New(), "tid_test")
+	msg.Headers["Message-Timestamp"] = "2020-06-01T12:29:00.000Z"
+
+	hook := logger.NewTestHook("")
+	require.NoError(t, m.Map(context.Background(), msg))
+
+	require.Len(t, producer.messages, 1)
+	assert.Equal(t, "2020-06-01T12:29:00.000Z", producer.messages[0].Headers[sourceMessageTimestampHeader])
+	assert.Equal(t, "2020-06-01T12:30:01.000Z", producer.messages[0].Headers["Message-Timestamp"])
+
+	entry := hook.LastEntry()
+	assert.Equal(t, "Successfully mapped", entry.Message)
+	assert.Equal(t, int64(2000), entry.Data["consumeToProduceLagMs"])
+	assert.Equal(t, int64(62000), entry.Data["publishToProduceLagMs"])
+}
+
+func TestMap__MeasuresTheConsumeLagFromWhenTheMessageWasConsumed(t *testing.T) {
+	producedAt := time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC)
+	m := NewMapper(MapperDependencies{
+		Producer: &mockProducer{},
+		Config:   newDefaultConfigStore(),
+		Clock:    func() time.Time { return producedAt },
+	})
+
+	hook := logger.NewTestHook("")
+	ctx := withConsumedAt(context.Background(), producedAt.Add(-3*time.Second))
+	require.NoError(t, m.Map(ctx, publishEventMessage(uuid.New(), "tid_test")))
+
+	entry := hook.LastEntry()
+	assert.Equal(t, "Successfully mapped", entry.Message)
+	assert.Equal(t, int64(3000), entry.Data["consumeToProduceLagMs"], "the time waiting for a worker is part of the lag")
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+)
+
+// The formats the concept annotations can be written to the queue in
+const (
+	conceptAnnotationsFormat = "conceptAnnotations"
+	pacAnnotationsFormat     = "pac"
+)
+
+const (
+	thingIDPrefix           = "http://www.ft.com/thing/"
+	apiURLPrefix            = "http://api.ft.com/"
+	annotationPredicate     = "http://www.ft.com/ontology/annotation/"
+	classificationPredicate = "http://www.ft.com/ontology/classification/"
+)
+
+// outputWriter renders the mapped concept annotations into the body of the message written to the queue
+type outputWriter interface {
+	write(conceptAnnotations mapper.ConceptAnnotations) ([]byte, error)
+	schema() *outputSchema
+}
+
+var outputWriters = map[string]outputWriter{
+	conceptAnnotationsFormat: conceptAnnotationsWriter{},
+	pacAnnotationsFormat:     pacAnnotationsWriter{},
+}
+
+func newOutputWriter(format string) (outputWriter, error) {
+	writer, found := outputWriters[format]
+	if !found {
+		formats := make([]string, 0, len(outputWriters))
+		for name := range outputWriters {
+			formats = append(formats, name)
+		}
+		sort.Strings(formats)
+		return nil, fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(formats, ", "))
+	}
+	return writer, nil
+}
+
+// conceptAnnotationsWriter writes the ConceptAnnotations as they are
+type conceptAnnotationsWriter struct{}
+
+func (conceptAnnotationsWriter) write(conceptAnnotations mapper.ConceptAnnotations) ([]byte, error) {
+	return json.Marshal(conceptAnnotations)
+}
+
+func (conceptAnnotationsWriter) schema() *outputSchema {
+	return conceptAnnotationsSchema
+}
+
+// pacAnnotations is the flat annotation schema of the newer UPP annotations writers
+type pacAnnotations struct {
+	UUID        string          `json:"uuid"`
+	Annotations []pacAnnotation `json:"annotations"`
+}
+
+type pacAnnotation struct {
+	ID              string   `json:"id"`
+	Predicate       string   `json:"predicate"`
+	APIURL          string   `json:"apiUrl"`
+	Type            string   `json:"type"`
+	PrefLabel       string   `json:"prefLabel,omitempty"`
+	RelevanceScore  *float32 `json:"relevanceScore,omitempty"`
+	ConfidenceScore *float32 `json:"confidenceScore,omitempty"`
+}
+
+// apiPaths are the API paths of the concept types that are not served as things
+var apiPaths = map[string]string{
+	mapper.PersonType:       "people",
+	mapper.OrganisationType: "organisations",
+	mapper.BrandType:        "brands",
+}
+
+// pacAnnotationsWriter writes every annotation as a flat object with full URIs for the concept, predicate and type
+type pacAnnotationsWriter struct{}
+
+func (pacAnnotationsWriter) write(conceptAnnotations mapper.ConceptAnnotations) ([]byte, error) {
+	annotations := make([]pacAnnotation, 0, len(conceptAnnotations.Annotations))
+	for _, a := range conceptAnnotations.Annotations {
+		annotations = append(annotations, toPACAnnotation(a))
+	}
+	return json.Marshal(pacAnnotations{UUID: conceptAnnotations.UUID, Annotations: annotations})
+}
+
+func (pacAnnotationsWriter) schema() *outputSchema {
+	return pacAnnotationsSchema
+}
+
+func toPACAnnotation(a mapper.Annotation) pacAnnotation {
+	conceptUUID := a.Thing.ID[strings.LastIndex(a.Thing.ID, "/")+1:]
+
+	var conceptType string
+	if len(a.Thing.Types) > 0 {
+		conceptType = a.Thing.Types[0]
+	}
+	apiPath, found := apiPaths[conceptType]
+	if !found {
+		apiPath = "things"
+	}
+
+	predicate := annotationPredicate + a.Thing.Predicate
+	if a.Thing.Predicate == mapper.IsClassifiedBy || a.Thing.Predicate == mapper.IsPrimarilyClassifiedBy {
+		predicate = classificationPredicate + a.Thing.Predicate
+	}
+
+	pac := pacAnnotation{
+		ID:        thingIDPrefix + conceptUUID,
+		Predicate: predicate,
+		APIURL:    apiURLPrefix + apiPath + "/" + conceptUUID,
+		Type:      conceptType,
+		PrefLabel: a.Thing.PrefLabel,
+	}
+	for _, p := range a.Provenance {
+		for _, s := range p.Scores {
+			value := s.Value
+			switch s.ScoringSystem {
+			case mapper.RelevanceScoringSystem:
+				pac.RelevanceScore = &value
+			case mapper.ConfidenceScoringSystem:
+				pac.ConfidenceScore = &value
+			}
+		}
+	}
+	return pac
+}
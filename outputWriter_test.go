@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOutputWriter(t *testing.T) {
+	writer, err := newOutputWriter(conceptAnnotationsFormat)
+	require.NoError(t, err)
+	assert.IsType(t, conceptAnnotationsWriter{}, writer)
+
+	writer, err = newOutputWriter(pacAnnotationsFormat)
+	require.NoError(t, err)
+	assert.IsType(t, pacAnnotationsWriter{}, writer)
+
+	_, err = newOutputWriter("annotations")
+	assert.EqualError(t, err, `unknown output format "annotations", expected one of conceptAnnotations, pac`)
+}
+
+func TestPACAnnotationsWriter(t *testing.T) {
+	conceptAnnotations := mapper.ConceptAnnotations{
+		UUID: "f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2",
+		Annotations: []mapper.Annotation{
+			{
+				Thing: mapper.Thing{
+					ID:        "http://api.ft.com/things/5507ab98-b747-3ebc-b816-11603b9a3a3c",
+					PrefLabel: "Economic News",
+					Predicate: mapper.IsClassifiedBy,
+					Types:     []string{mapper.SubjectType},
+				},
+				Provenance: []mapper.Provenance{{Scores: []mapper.Score{{ScoringSystem: mapper.RelevanceScoringSystem, Value: 0.8}, {ScoringSystem: mapper.ConfidenceScoringSystem, Value: 0.9}}}},
+			},
+			{
+				Thing: mapper.Thing{
+					ID:        "http://api.ft.com/things/e3ee0b5a-0ee9-3f3e-a1fa-ff3a3d5a4b0f",
+					PrefLabel: "Barack Obama",
+					Predicate: mapper.MajorMentions,
+					Types:     []string{mapper.PersonType},
+				},
+			},
+		},
+	}
+
+	body, err := pacAnnotationsWriter{}.write(conceptAnnotations)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"uuid": "f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2",
+		"annotations": [
+			{
+				"id": "http://www.ft.com/thing/5507ab98-b747-3ebc-b816-11603b9a3a3c",
+				"predicate": "http://www.ft.com/ontology/classification/isClassifiedBy",
+				"apiUrl": "http://api.ft.com/things/5507ab98-b747-3ebc-b816-11603b9a3a3c",
+				"type": "`+mapper.SubjectType+`",
+				"prefLabel": "Economic News",
+				"relevanceScore": 0.8,
+				"confidenceScore": 0.9
+			},
+			{
+				"id": "http://www.ft.com/thing/e3ee0b5a-0ee9-3f3e-a1fa-ff3a3d5a4b0f",
+				"predicate": "http://www.ft.com/ontology/annotation/majorMentions",
+				"apiUrl": "http://api.ft.com/people/e3ee0b5a-0ee9-3f3e-a1fa-ff3a3d5a4b0f",
+				"type": "`+mapper.PersonType+`",
+				"prefLabel": "Barack Obama"
+			}
+		]
+	}`, string(body))
+}
+
+func TestPACAnnotationsWriter__NoAnnotations(t *testing.T) {
+	body, err := pacAnnotationsWriter{}.write(mapper.ConceptAnnotations{UUID: "f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2"})
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"uuid": "f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2", "annotations": []}`, string(body))
+}
+
+func TestHandleMessage__PACOutputFormat(t *testing.T) {
+	producer := &mockProducer{}
+	m := newTestMapper(producer)
+	setTestConfig(m, func(config *mappingConfig) { config.output = pacAnnotationsWriter{} })
+
+	msg := publishEventMessage(uuid.New(), "tid_test")
+	msg.Headers["Content-Type"] = jsonMetadataContentType
+	msg.Body = `{"uuid":"` + uuid.New() + `","value":"` + base64.StdEncoding.EncodeToString([]byte(testJSONMetadata)) + `"}`
+
+	require.NoError(t, m.Map(context.Background(), msg))
+	require.Len(t, producer.messages, 1)
+
+	var annotations pacAnnotations
+	require.NoError(t, json.Unmarshal([]byte(producer.messages[0].Body), &annotations))
+	assert.Len(t, annotations.Annotations, 3)
+	for _, a := range annotations.Annotations {
+		assert.True(t, strings.HasPrefix(a.ID, thingIDPrefix), a.ID)
+		assert.NotEmpty(t, a.Type)
+	}
+}
+
+func TestMapHandler__PACOutputFormat(t *testing.T) {
+	m := newTestMapper(nil)
+	setTestConfig(m, func(config *mappingConfig) { config.output = pacAnnotationsWriter{} })
+
+	testUUID := uuid.New()
+	req := httptest.NewRequest("POST", "http://example.com/map?uuid="+testUUID, strings.NewReader(testJSONMetadata))
+	req.Header.Set("Content-Type", jsonMetadataContentType)
+	w := httptest.NewRecorder()
+
+	m.mapHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var annotations pacAnnotations
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &annotations))
+	assert.Equal(t, testUUID, annotations.UUID)
+	assert.Len(t, annotations.Annotations, 3)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	defaultProduceAttempts     = 5
+	defaultProduceRetryBackoff = 100 * time.Millisecond
+	maxProduceBackoff          = 5 * time.Second
+)
+
+// retryPolicy retries failed operations with an exponential backoff and jitter
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	// jitter is the fraction of each backoff that is randomised, so that instances failing together do not retry together
+	jitter float64
+	// retriable tells the errors worth retrying from the permanent ones
+	retriable func(err error) bool
+	// wait waits before a retry, and returns false if the context is done first
+	wait func(ctx context.Context, d time.Duration) bool
+}
+
+func newProduceRetryPolicy(maxAttempts int, initialBackoff time.Duration) retryPolicy {
+	return retryPolicy{
+		maxAttempts:    maxAttempts,
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxProduceBackoff,
+		jitter:         0.5,
+		retriable:      isRetriableProduceError,
+		wait:           waitOrDone,
+	}
+}
+
+func waitOrDone(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// do runs the operation until it succeeds, it fails with an error that is not retriable, the attempts are exhausted or the context is done.
+// retrying is called before waiting for each retry. It returns the number of attempts made and the last error.
+func (p retryPolicy) do(ctx context.Context, operation func() error, retrying func(attempt int, err error, backoff time.Duration)) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := operation()
+		if err == nil || attempt >= p.maxAttempts || !p.retriable(err) || ctx.Err() != nil {
+			return attempt, err
+		}
+		backoff := p.backoff(attempt)
+		retrying(attempt, err, backoff)
+		if !p.wait(ctx, backoff) {
+			return attempt, err
+		}
+	}
+}
+
+// backoff doubles after every attempt up to the maximum, and is randomly reduced by up to the jitter fraction
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.initialBackoff
+	for i := 1; i < attempt && backoff < p.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.maxBackoff {
+		backoff = p.maxBackoff
+	}
+	return backoff - time.Duration(rand.Float64()*p.jitter*float64(backoff))
+}
+
+// permanentProduceErrors are the broker errors that fail again however many times the message is sent
+var permanentProduceErrors = map[sarama.KError]bool{
+	sarama.ErrInvalidMessage:             true,
+	sarama.ErrInvalidMessageSize:         true,
+	sarama.ErrMessageSizeTooLarge:        true,
+	sarama.ErrMessageSetSizeTooLarge:     true,
+	sarama.ErrInvalidTopic:               true,
+	sarama.ErrInvalidRequiredAcks:        true,
+	sarama.ErrTopicAuthorizationFailed:   true,
+	sarama.ErrClusterAuthorizationFailed: true,
+	sarama.ErrUnsupportedVersion:         true,
+}
+
+// isRetriableProduceError considers retriable the errors of brokers being unavailable, changing leader or timing out,
+// and anything unexpected, since the retries are bounded. Invalid messages and configurations are permanent.
+func isRetriableProduceError(err error) bool {
+	switch e := err.(type) {
+	case sarama.KError:
+		return !permanentProduceErrors[e]
+	case sarama.ConfigurationError, sarama.PacketEncodingError:
+		return false
+	}
+	return err != sarama.ErrShuttingDown
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pborman/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRetryPolicy(maxAttempts int, sleeps *[]time.Duration) retryPolicy {
+	policy := newProduceRetryPolicy(maxAttempts, 100*time.Millisecond)
+	policy.jitter = 0
+	policy.wait = func(ctx context.Context, d time.Duration) bool {
+		*sleeps = append(*sleeps, d)
+		return ctx.Err() == nil
+	}
+	return policy
+}
+
+func TestRetryPolicy__RetriesTransientErrors(t *testing.T) {
+	var sleeps []time.Duration
+	policy := testRetryPolicy(5, &sleeps)
+
+	calls := 0
+	var retried []int
+	attempts, err := policy.do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return sarama.ErrNotLeaderForPartition
+		}
+		return nil
+	}, func(attempt int, err error, backoff time.Duration) {
+		retried = append(retried, attempt)
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []int{1, 2}, retried)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, sleeps)
+}
+
+func TestRetryPolicy__GivesUpAfterMaxAttempts(t *testing.T) {
+	var sleeps []time.Duration
+	policy := testRetryPolicy(3, &sleeps)
+
+	attempts, err := policy.do(context.Background(), func() error {
+		return sarama.ErrRequestTimedOut
+	}, func(attempt int, err error, backoff time.Duration) {})
+
+	assert.Equal(t, sarama.ErrRequestTimedOut, err)
+	assert.Equal(t, 3, attempts)
+	assert.Len(t, sleeps, 2)
+}
+
+func TestRetryPolicy__DoesNotRetryPermanentErrors(t *testing.T) {
+	var sleeps []time.Duration
+	policy := testRetryPolicy(5, &sleeps)
+
+	attempts, err := policy.do(context.Background(), func() error {
+		return sarama.ErrMessageSizeTooLarge
+	}, func(attempt int, err error, backoff time.Duration) {})
+
+	assert.Equal(t, sarama.ErrMessageSizeTooLarge, err)
+	assert.Equal(t, 1, attempts)
+	assert.Empty(t, sleeps)
+}
+
+func TestRetryPolicy__StopsWhenTheContextIsDone(t *testing.T) {
+	var sleeps []time.Duration
+	policy := testRetryPolicy(5, &sleeps)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts, err := policy.do(ctx, func() error {
+		return sarama.ErrRequestTimedOut
+	}, func(attempt int, err error, backoff time.Duration) {
+		cancel()
+	})
+
+	assert.Equal(t, sarama.ErrRequestTimedOut, err)
+	assert.Equal(t, 1, attempts)
+	assert.Len(t, sleeps, 1)
+}
+
+func TestRetryPolicy__StopsWaitingWhenTheContextIsDone(t *testing.T) {
+	policy := newProduceRetryPolicy(5, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	attempts, err := policy.do(ctx, func() error {
+		return sarama.ErrRequestTimedOut
+	}, func(attempt int, err error, backoff time.Duration) {})
+
+	assert.Equal(t, sarama.ErrRequestTimedOut, err)
+	assert.Equal(t, 1, attempts)
+	assert.True(t, time.Since(start) < time.Second, "the backoff was not interrupted")
+}
+
+func TestRetryPolicy__Backoff(t *testing.T) {
+	policy := newProduceRetryPolicy(10, 100*time.Millisecond)
+	policy.jitter = 0
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 800*time.Millisecond, policy.backoff(4))
+	assert.Equal(t, maxProduceBackoff, policy.backoff(9))
+
+	policy.jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := policy.backoff(2)
+		assert.True(t, backoff > 100*time.Millisecond && backoff <= 200*time.Millisecond, backoff.String())
+	}
+}
+
+func TestIsRetriableProduceError(t *testing.T) {
+	tests := []struct {
+		err       error
+		retriable bool
+	}{
+		{sarama.ErrNotLeaderForPartition, true},
+		{sarama.ErrLeaderNotAvailable, true},
+		{sarama.ErrNotEnoughReplicas, true},
+		{sarama.ErrOutOfBrokers, true},
+		{errProducerNotConnected, true},
+		{errors.New("unexpected"), true},
+		{sarama.ErrMessageSizeTooLarge, false},
+		{sarama.ErrTopicAuthorizationFailed, false},
+		{sarama.ConfigurationError("invalid"), false},
+		{sarama.PacketEncodingError{Info: "invalid"}, false},
+		{sarama.ErrShuttingDown, false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.retriable, isRetriableProduceError(test.err), test.err.Error())
+	}
+}
+
+func TestHandleMessage__ProduceFailuresAreRetriedThenSentToDeadLetter(t *testing.T) {
+	var sleeps []time.Duration
+	producer := &mockProducer{err: sarama.ErrLeaderNotAvailable}
+	dlq := &mockProducer{}
+	mapper := newTestMapper(producer)
+	mapper.retry = testRetryPolicy(3, &sleeps)
+	mapper.deadLetter = dlq
+	retries := testutil.ToFloat64(produceRetries)
+	failures := testutil.ToFloat64(messagesFailed.WithLabelValues(produceStage))
+
+	msg := publishEventMessage(uuid.New(), "tid_test")
+	err := mapper.Map(context.Background(), msg)
+
+	assert.Equal(t, sarama.ErrLeaderNotAvailable, err)
+	assert.Len(t, producer.messages, 3)
+	assert.Equal(t, retries+2, testutil.ToFloat64(produceRetries))
+	assert.Equal(t, failures+1, testutil.ToFloat64(messagesFailed.WithLabelValues(produceStage)))
+	require.Len(t, dlq.messages, 1)
+	assert.Equal(t, msg.Body, dlq.messages[0].Body)
+	assert.Equal(t, produceStage, dlq.messages[0].Headers[failureStageHeader])
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	logger "github.com/Financial-Times/go-logger"
+)
+
+// The sources the mapping configuration can be applied from
+const (
+	startupConfigSource = "startup"
+	fileConfigSource    = "file"
+	adminConfigSource   = "admin"
+)
+
+const (
+	configAppliedEvent = "config_applied"
+	defaultWhitelist   = "http://cmdb\\.ft\\.com/systems/methode-web-pub"
+)
+
+// mappingSettings are the settings of the mapping that can be changed at runtime, by the runtime configuration file or the admin endpoint.
+// The taxonomies are in the same format as in the taxonomy mapping configuration file. There are no filters unless they are set at runtime.
+type mappingSettings struct {
+	Version           string                `json:"version"`
+	Whitelist         string                `json:"whitelist"`
+	Taxonomies        []mapper.TaxonomyRule `json:"taxonomies"`
+	IgnoredTaxonomies []string              `json:"ignoredTaxonomies"`
+	ValidationPolicy  string                `json:"validationPolicy"`
+	OutputFormat      string                `json:"outputFormat"`
+	SchemaCheck       string                `json:"schemaCheck"`
+	Filters           []filterRule          `json:"filters"`
+	PropagatedHeaders []string              `json:"propagatedHeaders"`
+	SourceHeaders     bool                  `json:"sourceHeaders"`
+}
+
+// settingsOverlay are the settings given by the runtime configuration file or the admin endpoint, which replace the ones of the command line options.
+// Settings that are not given keep the value of the command line options.
+type settingsOverlay struct {
+	Version           *string                `json:"version"`
+	Whitelist         *string                `json:"whitelist"`
+	Taxonomies        *[]mapper.TaxonomyRule `json:"taxonomies"`
+	IgnoredTaxonomies *[]string              `json:"ignoredTaxonomies"`
+	ValidationPolicy  *string                `json:"validationPolicy"`
+	OutputFormat      *string                `json:"outputFormat"`
+	SchemaCheck       *string                `json:"schemaCheck"`
+	Filters           *[]filterRule          `json:"filters"`
+	PropagatedHeaders *[]string              `json:"propagatedHeaders"`
+	SourceHeaders     *bool                  `json:"sourceHeaders"`
+}
+
+// overlay returns the settings with the ones given in the JSON document replacing them
+func (s mappingSettings) overlay(document []byte) (mappingSettings, error) {
+	dec := json.NewDecoder(bytes.NewReader(document))
+	dec.DisallowUnknownFields()
+	var o settingsOverlay
+	if err := dec.Decode(&o); err != nil {
+		return s, fmt.Errorf("cannot parse runtime configuration: %v", err)
+	}
+
+	s.Version = ""
+	if o.Version != nil {
+		s.Version = *o.Version
+	}
+	if o.Whitelist != nil {
+		s.Whitelist = *o.Whitelist
+	}
+	if o.Taxonomies != nil {
+		s.Taxonomies = *o.Taxonomies
+	}
+	if o.IgnoredTaxonomies != nil {
+		s.IgnoredTaxonomies = *o.IgnoredTaxonomies
+	}
+	if o.ValidationPolicy != nil {
+		s.ValidationPolicy = *o.ValidationPolicy
+	}
+	if o.OutputFormat != nil {
+		s.OutputFormat = *o.OutputFormat
+	}
+	if o.SchemaCheck != nil {
+		s.SchemaCheck = *o.SchemaCheck
+	}
+	if o.Filters != nil {
+		s.Filters = *o.Filters
+	}
+	if o.PropagatedHeaders != nil {
+		s.PropagatedHeaders = *o.PropagatedHeaders
+	}
+	if o.SourceHeaders != nil {
+		s.SourceHeaders = *o.SourceHeaders
+	}
+	return s, nil
+}
+
+// hash identifies the settings when they are not given a version
+func (s mappingSettings) hash() string {
+	s.Version = ""
+	b, _ := json.Marshal(s)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:6])
+}
+
+// mappingConfig is everything a Mapper maps a message with. It is never modified once applied:
+// a reload builds a new one and swaps it in atomically, so every message is mapped with a single version of the configuration.
+type mappingConfig struct {
+	settings         mappingSettings
+	source           string
+	appliedAt        time.Time
+	whitelist        *regexp.Regexp
+	filter           *messageFilter
+	taxonomies       *mapper.TaxonomyRegistry
+	validator        metadataValidator
+	validationPolicy string
+	output           outputWriter
+	schemaCheck      string
+	headers          headerPolicy
+}
+
+func newMappingConfig(settings mappingSettings, source string) (*mappingConfig, error) {
+	whitelist, err := regexp.Compile(settings.Whitelist)
+	if err != nil {
+		return nil, fmt.Errorf("invalid whitelist: %v", err)
+	}
+	filter, err := newMessageFilter(settings.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filters: %v", err)
+	}
+	taxonomies, err := mapper.NewTaxonomyRegistry(settings.Taxonomies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid taxonomies: %v", err)
+	}
+	switch settings.ValidationPolicy {
+	case rejectInvalidPolicy, warnInvalidPolicy:
+	default:
+		return nil, fmt.Errorf("unknown validation policy %q", settings.ValidationPolicy)
+	}
+	output, err := newOutputWriter(settings.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	switch settings.SchemaCheck {
+	case offSchemaCheck, auditSchemaCheck, strictSchemaCheck:
+	default:
+		return nil, fmt.Errorf("unknown schema check %q", settings.SchemaCheck)
+	}
+	headers, err := newHeaderPolicy(settings.PropagatedHeaders, settings.SourceHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("invalid propagated headers: %v", err)
+	}
+
+	if settings.Version == "" {
+		settings.Version = settings.hash()
+	}
+	return &mappingConfig{
+		settings:         settings,
+		source:           source,
+		appliedAt:        time.Now().UTC(),
+		whitelist:        whitelist,
+		filter:           filter,
+		taxonomies:       taxonomies,
+		validator:        newMetadataValidator(settings.Taxonomies, settings.IgnoredTaxonomies),
+		validationPolicy: settings.ValidationPolicy,
+		output:           output,
+		schemaCheck:      settings.SchemaCheck,
+		headers:          headers,
+	}, nil
+}
+
+// configStore holds the mapping configuration of a mapper, and swaps in the configurations applied to it atomically
+type configStore struct {
+	value atomic.Value
+}
+
+func newConfigStore(config *mappingConfig) *configStore {
+	s := &configStore{}
+	s.value.Store(config)
+	return s
+}
+
+// current returns the mapping configuration to map a message with. Take it once per message, and not again while mapping it.
+func (s *configStore) current() *mappingConfig {
+	return s.value.Load().(*mappingConfig)
+}
+
+func (s *configStore) apply(config *mappingConfig) {
+	s.value.Store(config)
+	configReloads.WithLabelValues(config.source, "applied").Inc()
+	logger.Infof(map[string]interface{}{"event": configAppliedEvent, "configVersion": config.settings.Version, "configSource": config.source},
+		"Applied mapping configuration version %s", config.settings.Version)
+}
+
+// configWatcher applies the runtime configuration file over the settings of the command line options, again every time its content changes
+type configWatcher struct {
+	store    *configStore
+	base     mappingSettings
+	path     string
+	interval time.Duration
+	mutex    sync.Mutex
+	lastHash [sha256.Size]byte
+}
+
+func newConfigWatcher(store *configStore, base mappingSettings, path string, interval time.Duration) *configWatcher {
+	return &configWatcher{store: store, base: base, path: path, interval: interval}
+}
+
+// load applies the runtime configuration file if its content changed since it was last loaded. A file that cannot be applied
+// leaves the mapping configuration as it is, and is not tried again until its content changes.
+func (w *configWatcher) load() error {
+	document, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	hash := sha256.Sum256(document)
+	if hash == w.lastHash {
+		return nil
+	}
+	w.lastHash = hash
+
+	settings, err := w.base.overlay(document)
+	if err == nil {
+		var config *mappingConfig
+		if config, err = newMappingConfig(settings, fileConfigSource); err == nil {
+			w.store.apply(config)
+			return nil
+		}
+	}
+	configReloads.WithLabelValues(fileConfigSource, "failed").Inc()
+	return fmt.Errorf("cannot apply runtime configuration %s: %v", w.path, err)
+}
+
+// watch loads the runtime configuration file every interval, until stop is closed
+func (w *configWatcher) watch(stop <-chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := w.load(); err != nil {
+				logger.NewEntry("").WithError(err).Error("Error reloading the mapping configuration, keeping the current one")
+			}
+		}
+	}
+}
+
+// configStatus describes the active mapping configuration
+type configStatus struct {
+	Version   string          `json:"version"`
+	Source    string          `json:"source"`
+	AppliedAt time.Time       `json:"appliedAt"`
+	Settings  mappingSettings `json:"settings"`
+}
+
+func newConfigStatus(config *mappingConfig) configStatus {
+	return configStatus{Version: config.settings.Version, Source: config.source, AppliedAt: config.appliedAt, Settings: config.settings}
+}
+
+// configAdmin serves the active mapping configuration, and applies the runtime configuration put by authenticated requests
+// over the settings of the command line options
+type configAdmin struct {
+	store *configStore
+	base  mappingSettings
+	token string
+}
+
+func (a configAdmin) getConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, newConfigStatus(a.store.current()))
+}
+
+func (a configAdmin) putConfig(w http.ResponseWriter, r *http.Request) {
+	if a.token == "" {
+		writeJSONResponse(w, http.StatusForbidden, errorMessage{Message: "Changing the mapping configuration is disabled, no admin token is configured"})
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+		writeJSONResponse(w, http.StatusUnauthorized, errorMessage{Message: "Invalid admin token"})
+		return
+	}
+
+	document, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, errorMessage{Message: "Cannot read request body"})
+		return
+	}
+	settings, err := a.base.overlay(document)
+	if err != nil {
+		configReloads.WithLabelValues(adminConfigSource, "failed").Inc()
+		writeJSONResponse(w, http.StatusBadRequest, errorMessage{Message: err.Error()})
+		return
+	}
+	config, err := newMappingConfig(settings, adminConfigSource)
+	if err != nil {
+		configReloads.WithLabelValues(adminConfigSource, "failed").Inc()
+		writeJSONResponse(w, http.StatusBadRequest, errorMessage{Message: "Invalid runtime configuration: " + err.Error()})
+		return
+	}
+
+	a.store.apply(config)
+	writeJSONResponse(w, http.StatusOK, newConfigStatus(config))
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultSettings are the settings of the default command line options
+var defaultSettings = mappingSettings{
+	Whitelist:         defaultWhitelist,
+	Taxonomies:        mapper.DefaultTaxonomyRules,
+	IgnoredTaxonomies: defaultIgnoredTaxonomies,
+	ValidationPolicy:  warnInvalidPolicy,
+	OutputFormat:      conceptAnnotationsFormat,
+	SchemaCheck:       auditSchemaCheck,
+	SourceHeaders:     true,
+}
+
+// newDefaultConfigStore holds the mapping configuration of the default settings
+func newDefaultConfigStore() *configStore {
+	config, err := newMappingConfig(defaultSettings, startupConfigSource)
+	if err != nil {
+		panic(err)
+	}
+	return newConfigStore(config)
+}
+
+func TestMappingSettings__Overlay(t *testing.T) {
+	base := defaultSettings
+	base.Version = "base"
+
+	settings, err := base.overlay([]byte(`{"whitelist": "http://cmdb\\.ft\\.com/systems/.*", "taxonomies": [{"name": "brands", "taxonomy": "Brands", "type": "http://www.ft.com/ontology/Brand", "predicate": "isClassifiedBy"}]}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, "", settings.Version)
+	assert.Equal(t, "http://cmdb\\.ft\\.com/systems/.*", settings.Whitelist)
+	assert.Equal(t, []mapper.TaxonomyRule{{Name: "brands", Taxonomy: "Brands", Type: mapper.BrandType, Predicate: mapper.IsClassifiedBy}}, settings.Taxonomies)
+	assert.Equal(t, defaultIgnoredTaxonomies, settings.IgnoredTaxonomies)
+	assert.Equal(t, warnInvalidPolicy, settings.ValidationPolicy)
+	assert.Equal(t, mapper.DefaultTaxonomyRules, base.Taxonomies)
+
+	_, err = base.overlay([]byte(`{"whitelist": ".*", "unknown": true}`))
+	assert.Error(t, err)
+}
+
+func TestNewMappingConfig(t *testing.T) {
+	config, err := newMappingConfig(defaultSettings, startupConfigSource)
+	require.NoError(t, err)
+	assert.Equal(t, defaultSettings.hash(), config.settings.Version)
+	assert.Len(t, config.settings.Version, 12)
+	assert.Len(t, config.taxonomies.Names(), len(mapper.DefaultTaxonomyRules))
+
+	versioned := defaultSettings
+	versioned.Version = "2020-06-01"
+	config, err = newMappingConfig(versioned, fileConfigSource)
+	require.NoError(t, err)
+	assert.Equal(t, "2020-06-01", config.settings.Version)
+	assert.Equal(t, fileConfigSource, config.source)
+
+	tests := []struct {
+		name      string
+		configure func(s *mappingSettings)
+	}{
+		{"whitelist", func(s *mappingSettings) { s.Whitelist = "(" }},
+		{"taxonomies", func(s *mappingSettings) { s.Taxonomies = nil }},
+		{"validation policy", func(s *mappingSettings) { s.ValidationPolicy = "ignore" }},
+		{"output format", func(s *mappingSettings) { s.OutputFormat = "xml" }},
+		{"schema check", func(s *mappingSettings) { s.SchemaCheck = "lenient" }},
+		{"propagated headers", func(s *mappingSettings) { s.PropagatedHeaders = []string{"Message-Id"} }},
+	}
+	for _, test := range tests {
+		settings := defaultSettings
+		test.configure(&settings)
+		_, err := newMappingConfig(settings, startupConfigSource)
+		assert.Error(t, err, test.name)
+	}
+}
+
+func TestMappingSettings__HashChangesWithTheSettings(t *testing.T) {
+	settings := defaultSettings
+	settings.SchemaCheck = strictSchemaCheck
+
+	assert.Equal(t, defaultSettings.hash(), defaultSettings.hash())
+	assert.NotEqual(t, defaultSettings.hash(), settings.hash())
+}
+
+func TestConfigWatcher__Load(t *testing.T) {
+	store := newDefaultConfigStore()
+
+	f, err := ioutil.TempFile("", "runtime-config-*.json")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte(`{"version": "v1", "validationPolicy": "reject"}`), 0644))
+
+	watcher := newConfigWatcher(store, defaultSettings, f.Name(), 0)
+	require.NoError(t, watcher.load())
+	applied := store.current()
+	assert.Equal(t, "v1", applied.settings.Version)
+	assert.Equal(t, fileConfigSource, applied.source)
+	assert.Equal(t, rejectInvalidPolicy, applied.validationPolicy)
+
+	require.NoError(t, watcher.load())
+	assert.True(t, applied == store.current(), "an unchanged file is not applied again")
+
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte(`{"version": "v2", "whitelist": "("}`), 0644))
+	assert.Error(t, watcher.load())
+	assert.True(t, applied == store.current(), "an invalid file leaves the configuration as it is")
+	assert.NoError(t, watcher.load(), "an invalid file is not tried again until it changes")
+
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte(`{"version": "v3"}`), 0644))
+	require.NoError(t, watcher.load())
+	assert.Equal(t, "v3", store.current().settings.Version)
+	assert.Equal(t, warnInvalidPolicy, store.current().validationPolicy)
+}
+
+func TestConfigAdmin__GetConfig(t *testing.T) {
+	store := newDefaultConfigStore()
+	w := httptest.NewRecorder()
+
+	configAdmin{store: store}.getConfig(w, httptest.NewRequest("GET", "http://example.com/__config", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var status configStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, store.current().settings.Version, status.Version)
+	assert.Equal(t, store.current().source, status.Source)
+	assert.Equal(t, store.current().settings.Whitelist, status.Settings.Whitelist)
+}
+
+func TestConfigAdmin__PutConfig(t *testing.T) {
+	store := newDefaultConfigStore()
+	admin := configAdmin{store: store, base: defaultSettings, token: "secret"}
+
+	tests := []struct {
+		name           string
+		admin          configAdmin
+		authorization  string
+		body           string
+		expectedStatus int
+	}{
+		{"disabled", configAdmin{store: store, base: defaultSettings}, "Bearer ", `{"version": "v1"}`, http.StatusForbidden},
+		{"missing token", admin, "", `{"version": "v1"}`, http.StatusUnauthorized},
+		{"wrong token", admin, "Bearer guess", `{"version": "v1"}`, http.StatusUnauthorized},
+		{"invalid JSON", admin, "Bearer secret", `{"version": `, http.StatusBadRequest},
+		{"invalid settings", admin, "Bearer secret", `{"outputFormat": "xml"}`, http.StatusBadRequest},
+	}
+	previous := store.current()
+	for _, test := range tests {
+		req := httptest.NewRequest("PUT", "http://example.com/__config", strings.NewReader(test.body))
+		req.Header.Set("Authorization", test.authorization)
+		w := httptest.NewRecorder()
+
+		test.admin.putConfig(w, req)
+
+		assert.Equal(t, test.expectedStatus, w.Code, test.name)
+		assert.True(t, previous == store.current(), test.name)
+	}
+
+	req := httptest.NewRequest("PUT", "http://example.com/__config", strings.NewReader(`{"whitelist": "http://cmdb\\.ft\\.com/systems/pac"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+
+	admin.putConfig(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var status configStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, adminConfigSource, status.Source)
+	assert.Equal(t, store.current().settings.Version, status.Version)
+	assert.True(t, store.current().whitelist.MatchString("http://cmdb.ft.com/systems/pac"))
+	assert.False(t, store.current().whitelist.MatchString("http://cmdb.ft.com/systems/methode-web-pub"))
+}
+
+func TestHandleMessage__UsesTheAppliedConfig(t *testing.T) {
+	producer := &mockProducer{}
+	m := newTestMapper(producer)
+
+	settings := defaultSettings
+	settings.Whitelist = "http://cmdb\\.ft\\.com/systems/pac"
+	config, err := newMappingConfig(settings, adminConfigSource)
+	require.NoError(t, err)
+	m.config.apply(config)
+
+	require.NoError(t, m.Map(context.Background(), publishEventMessage("f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2", "tid_test")))
+	assert.Empty(t, producer.messages)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	logger "github.com/Financial-Times/go-logger"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// The ways the concept annotations written to the queue are checked against the JSON Schema of the output format
+const (
+	offSchemaCheck    = "off"
+	auditSchemaCheck  = "audit"
+	strictSchemaCheck = "strict"
+)
+
+const schemaContentType = "application/schema+json"
+
+const conceptAnnotationsSchemaDocument = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "http://api.ft.com/schemas/annotations-mapper/concept-annotations.json",
+  "title": "ConceptAnnotations",
+  "description": "The concept annotations of a piece of content, as written to the ConceptAnnotations topic by the annotations mapper",
+  "type": "object",
+  "required": ["uuid", "annotations"],
+  "additionalProperties": false,
+  "properties": {
+    "uuid": {
+      "description": "The UUID of the annotated content",
+      "type": "string",
+      "pattern": "^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$"
+    },
+    "annotations": {
+      "description": "The annotations, ordered by predicate from the strongest to the weakest, then by concept type and concept ID",
+      "type": "array",
+      "items": {"$ref": "#/definitions/annotation"}
+    }
+  },
+  "definitions": {
+    "annotation": {
+      "type": "object",
+      "required": ["thing"],
+      "additionalProperties": false,
+      "properties": {
+        "thing": {"$ref": "#/definitions/thing"},
+        "provenances": {
+          "type": "array",
+          "items": {"$ref": "#/definitions/provenance"}
+        }
+      }
+    },
+    "thing": {
+      "type": "object",
+      "required": ["id", "prefLabel", "predicate", "types"],
+      "additionalProperties": false,
+      "properties": {
+        "id": {
+          "description": "The URI of the concept",
+          "type": "string",
+          "pattern": "^http://api\\.ft\\.com/things/[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$"
+        },
+        "prefLabel": {"type": "string"},
+        "predicate": {
+          "type": "string",
+          "enum": ["about", "isPrimarilyClassifiedBy", "majorMentions", "isClassifiedBy", "mentions", "hasAuthor"]
+        },
+        "types": {
+          "description": "The URIs of the concept types",
+          "type": "array",
+          "minItems": 1,
+          "items": {"type": "string", "pattern": "^https?://"}
+        }
+      }
+    },
+    "provenance": {
+      "type": "object",
+      "required": ["scores"],
+      "additionalProperties": false,
+      "properties": {
+        "scores": {
+          "type": "array",
+          "items": {"$ref": "#/definitions/score"}
+        }
+      }
+    },
+    "score": {
+      "type": "object",
+      "required": ["scoringSystem", "value"],
+      "additionalProperties": false,
+      "properties": {
+        "scoringSystem": {
+          "type": "string",
+          "enum": ["http://api.ft.com/scoringsystem/FT-RELEVANCE-SYSTEM", "http://api.ft.com/scoringsystem/FT-CONFIDENCE-SYSTEM"]
+        },
+        "value": {"type": "number", "minimum": 0, "maximum": 1}
+      }
+    }
+  }
+}
+`
+
+const pacAnnotationsSchemaDocument = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "http://api.ft.com/schemas/annotations-mapper/pac-annotations.json",
+  "title": "PAC annotations",
+  "description": "The flat annotations of a piece of content, as written by the annotations mapper with the pac output format",
+  "type": "object",
+  "required": ["uuid", "annotations"],
+  "additionalProperties": false,
+  "properties": {
+    "uuid": {
+      "description": "The UUID of the annotated content",
+      "type": "string",
+      "pattern": "^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$"
+    },
+    "annotations": {
+      "type": "array",
+      "items": {"$ref": "#/definitions/annotation"}
+    }
+  },
+  "definitions": {
+    "annotation": {
+      "type": "object",
+      "required": ["id", "predicate", "apiUrl", "type"],
+      "additionalProperties": false,
+      "properties": {
+        "id": {
+          "type": "string",
+          "pattern": "^http://www\\.ft\\.com/thing/[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$"
+        },
+        "predicate": {
+          "type": "string",
+          "pattern": "^http://www\\.ft\\.com/ontology/(annotation|classification)/"
+        },
+        "apiUrl": {
+          "type": "string",
+          "pattern": "^http://api\\.ft\\.com/[a-z]+/[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$"
+        },
+        "type": {"type": "string", "pattern": "^https?://"},
+        "prefLabel": {"type": "string"},
+        "relevanceScore": {"type": "number", "minimum": 0, "maximum": 1},
+        "confidenceScore": {"type": "number", "minimum": 0, "maximum": 1}
+      }
+    }
+  }
+}
+`
+
+var (
+	conceptAnnotationsSchema = mustCompileSchema(conceptAnnotationsSchemaDocument)
+	pacAnnotationsSchema     = mustCompileSchema(pacAnnotationsSchemaDocument)
+)
+
+// outputSchema is the JSON Schema that the concept annotations written in an output format conform to
+type outputSchema struct {
+	document string
+	schema   *gojsonschema.Schema
+}
+
+func mustCompileSchema(document string) *outputSchema {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(document))
+	if err != nil {
+		panic(err)
+	}
+	return &outputSchema{document: document, schema: schema}
+}
+
+// violations describes every way the payload does not conform to the schema
+func (s *outputSchema) violations(payload []byte) []string {
+	result, err := s.schema.Validate(gojsonschema.NewBytesLoader(payload))
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var violations []string
+	for _, e := range result.Errors() {
+		violations = append(violations, e.String())
+	}
+	return violations
+}
+
+// schemaViolationError is returned for messages rejected because their concept annotations do not conform to the schema
+type schemaViolationError struct {
+	violations []string
+}
+
+func (e schemaViolationError) Error() string {
+	return "concept annotations do not conform to the schema: " + strings.Join(e.violations, "; ")
+}
+
+// schemaHandler serves the JSON Schema of the concept annotations written in the output format of the mapper
+func (m *Mapper) schemaHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", schemaContentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(m.config.current().output.schema().document)); err != nil {
+		logger.NewEntry(r.Header.Get("X-Request-Id")).WithError(err).Error("Error writing response")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputSchema__MappedAnnotationsConform(t *testing.T) {
+	metadata, err := jsonMetadataDecoder{}.decode([]byte(testJSONMetadata))
+	require.NoError(t, err)
+	registry, err := mapper.NewTaxonomyRegistry(mapper.DefaultTaxonomyRules)
+	require.NoError(t, err)
+	conceptAnnotations := mapper.MapToConceptAnnotations(registry, uuid.New(), metadata)
+	require.NotEmpty(t, conceptAnnotations.Annotations)
+
+	for _, writer := range []outputWriter{conceptAnnotationsWriter{}, pacAnnotationsWriter{}} {
+		payload, err := writer.write(conceptAnnotations)
+		require.NoError(t, err)
+		assert.Empty(t, writer.schema().violations(payload))
+	}
+}
+
+func TestOutputSchema__UppercaseContentUUIDConforms(t *testing.T) {
+	metadata, err := jsonMetadataDecoder{}.decode([]byte(testJSONMetadata))
+	require.NoError(t, err)
+	registry, err := mapper.NewTaxonomyRegistry(mapper.DefaultTaxonomyRules)
+	require.NoError(t, err)
+	conceptAnnotations := mapper.MapToConceptAnnotations(registry, strings.ToUpper(uuid.New()), metadata)
+
+	for _, writer := range []outputWriter{conceptAnnotationsWriter{}, pacAnnotationsWriter{}} {
+		payload, err := writer.write(conceptAnnotations)
+		require.NoError(t, err)
+		assert.Empty(t, writer.schema().violations(payload))
+	}
+}
+
+func TestOutputSchema__Violations(t *testing.T) {
+	payload := `{
+		"uuid": "f3b60ad0-acda-11e7-b27d-5a4d1bb4f5b2",
+		"annotations": [
+			{
+				"thing": {"id": "http://api.ft.com/things/5507ab98", "prefLabel": "Economic News", "predicate": "isClassifiedBy"},
+				"provenances": [{"scores": [{"scoringSystem": "http://api.ft.com/scoringsystem/FT-RELEVANCE-SYSTEM", "value": 80}]}]
+			}
+		],
+		"extra": true
+	}`
+
+	violations := conceptAnnotationsSchema.violations([]byte(payload))
+
+	assert.Len(t, violations, 4)
+	assert.Contains(t, violations, "(root): Additional property extra is not allowed")
+	assert.Contains(t, violations, "annotations.0.thing: types is required")
+
+	assert.Len(t, conceptAnnotationsSchema.violations([]byte(`not json`)), 1)
+}
+
+func TestHandleMessage__StrictSchemaCheckRejectsNonConformingAnnotations(t *testing.T) {
+	producer := &mockProducer{}
+	dlq := &mockProducer{}
+	m := newTestMapper(producer)
+	m.deadLetter = dlq
+	setTestConfig(m, func(config *mappingConfig) { config.schemaCheck = strictSchemaCheck })
+
+	err := m.Map(context.Background(), publishEventMessage("not-a-uuid", "tid_test"))
+
+	assert.IsType(t, schemaViolationError{}, err)
+	assert.Empty(t, producer.messages)
+	require.Len(t, dlq.messages, 1)
+	assert.Equal(t, schemaStage, dlq.messages[0].Headers[failureStageHeader])
+}
+
+func TestHandleMessage__AuditSchemaCheckWritesNonConformingAnnotations(t *testing.T) {
+	producer := &mockProducer{}
+	m := newTestMapper(producer)
+
+	require.NoError(t, m.Map(context.Background(), publishEventMessage("not-a-uuid", "tid_test")))
+	assert.Len(t, producer.messages, 1)
+}
+
+func TestSchemaHandler(t *testing.T) {
+	for _, writer := range []outputWriter{conceptAnnotationsWriter{}, pacAnnotationsWriter{}} {
+		m := newTestMapper(nil)
+		setTestConfig(m, func(config *mappingConfig) { config.output = writer })
+		w := httptest.NewRecorder()
+
+		m.schemaHandler(w, httptest.NewRequest("GET", "http://example.com/schema", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, schemaContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, writer.schema().document, w.Body.String())
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/go-logger"
+	"github.com/Financial-Times/kafka-client-go/kafka"
+)
+
+// errShuttingDown is returned for messages delivered after the shutdown started. They are not handled, and their offset
+// must not be committed, so that they are consumed again after the restart.
+var errShuttingDown = errors.New("the service is shutting down")
+
+// messageTracker keeps count of the messages being handled, so that the shutdown can wait for them.
+// The messages are handled with its context, which the shutdown cancels when it stops waiting for them.
+type messageTracker struct {
+	mutex    sync.Mutex
+	stopped  bool
+	inFlight sync.WaitGroup
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// track wraps a message handler, refusing the messages delivered once the tracker is stopped
+func (t *messageTracker) track(handler func(ctx context.Context, msg kafka.FTMessage) error) func(msg kafka.FTMessage) error {
+	return func(msg kafka.FTMessage) error {
+		ctx, ok := t.begin()
+		if !ok {
+			return errShuttingDown
+		}
+		defer t.inFlight.Done()
+		return handler(ctx, msg)
+	}
+}
+
+// begin counts a message in flight and returns the context to handle it with, unless the tracker is stopped
+func (t *messageTracker) begin() (context.Context, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.stopped {
+		return nil, false
+	}
+	t.inFlight.Add(1)
+	return t.handlingContext(), true
+}
+
+// handlingContext must be called with the mutex held
+func (t *messageTracker) handlingContext() context.Context {
+	if t.ctx == nil {
+		t.ctx, t.cancel = context.WithCancel(context.Background())
+	}
+	return t.ctx
+}
+
+// stop refuses any new message
+func (t *messageTracker) stop() {
+	t.mutex.Lock()
+	t.stopped = true
+	t.mutex.Unlock()
+}
+
+// abandon cancels the context of the messages still in flight, so that they stop waiting to retry
+func (t *messageTracker) abandon() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.handlingContext()
+	t.cancel()
+}
+
+// wait waits for the messages in flight to be handled, and returns false if they were not handled before the deadline
+func (t *messageTracker) wait(deadline time.Time) bool {
+	done := make(chan struct{})
+	go func() {
+		t.inFlight.Wait()
+		close(done)
+	}()
+	return waitUntil(done, deadline)
+}
+
+func waitUntil(done <-chan struct{}, deadline time.Time) bool {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// shutdown stops the service in order: it stops accepting new messages, finishes the messages in flight before the timeout,
+// cancelling the context of those not finished by then, closes the producers once nothing is left to produce,
+// and stops the HTTP server last, so that the health checks stay available.
+//
+// The broker consumer leaves the messages refused with errShuttingDown unmarked, so they are refused as soon as the shutdown starts.
+// The Zookeeper consumer commits the offset of every message it delivers, refused or not: it is stopped first, and the messages
+// it delivers while stopping are still mapped. Once it is stopped, the offsets of any message it still delivers are no longer committed.
+func shutdown(consumer kafka.Consumer, producers []kafka.Producer, tracker *messageTracker, pool *workerPool, server *http.Server, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	logger.Infof(nil, "Shutting down, waiting up to %v for the messages in flight", timeout)
+
+	_, refusedLeftUnmarked := consumer.(*brokerConsumer)
+	if refusedLeftUnmarked {
+		tracker.stop()
+	}
+	consumerStopped := make(chan struct{})
+	go func() {
+		consumer.Shutdown()
+		close(consumerStopped)
+	}()
+	consumerDone := waitUntil(consumerStopped, deadline)
+	if !consumerDone {
+		logger.Warnf(nil, "The queue consumer did not stop in time")
+	}
+	tracker.stop()
+	drained := tracker.wait(deadline)
+	if !drained {
+		logger.Warnf(nil, "Messages in flight were not mapped in time, they will be consumed again after the restart")
+	}
+	// the producers are closed next, so the messages still in flight stop retrying
+	tracker.abandon()
+	if pool != nil && consumerDone && drained {
+		pool.close()
+	}
+
+	for _, producer := range producers {
+		producer.Shutdown()
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.NewEntry("").WithError(err).Error("Error stopping the HTTP server")
+	}
+	logger.Infof(nil, "Shutdown complete")
+}
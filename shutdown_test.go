@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageTracker__RefusesMessagesOnceStopped(t *testing.T) {
+	tracker := &messageTracker{}
+	handled := 0
+	handler := tracker.track(func(ctx context.Context, msg kafka.FTMessage) error {
+		handled++
+		return nil
+	})
+
+	assert.NoError(t, handler(kafka.FTMessage{}))
+	tracker.stop()
+	assert.Equal(t, errShuttingDown, handler(kafka.FTMessage{}))
+	assert.Equal(t, 1, handled)
+}
+
+func TestMessageTracker__WaitsForMessagesInFlight(t *testing.T) {
+	tracker := &messageTracker{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := tracker.track(func(ctx context.Context, msg kafka.FTMessage) error {
+		close(started)
+		<-release
+		return nil
+	})
+	go handler(kafka.FTMessage{})
+	<-started
+
+	tracker.stop()
+	assert.False(t, tracker.wait(time.Now().Add(50*time.Millisecond)), "the message is still in flight")
+
+	close(release)
+	assert.True(t, tracker.wait(time.Now().Add(time.Second)))
+}
+
+func TestShutdown__CancelsTheMessagesInFlightAtTheTimeout(t *testing.T) {
+	mutex := &sync.Mutex{}
+	var calls []string
+	consumer := orderedShutdown{mutex: mutex, calls: &calls, name: "consumer"}
+
+	tracker := &messageTracker{}
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	handler := tracker.track(func(ctx context.Context, msg kafka.FTMessage) error {
+		close(started)
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return ctx.Err()
+	})
+	go handler(kafka.FTMessage{})
+	<-started
+
+	shutdown(consumer, nil, tracker, nil, &http.Server{}, 50*time.Millisecond)
+
+	select {
+	case err := <-cancelled:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "the message in flight was not cancelled")
+	}
+}
+
+type orderedShutdown struct {
+	mutex *sync.Mutex
+	calls *[]string
+	name  string
+}
+
+func (s orderedShutdown) StartListening(messageHandler func(message kafka.FTMessage) error) {}
+
+func (s orderedShutdown) SendMessage(message kafka.FTMessage) error {
+	return nil
+}
+
+func (s orderedShutdown) ConnectivityCheck() error {
+	return nil
+}
+
+func (s orderedShutdown) Shutdown() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	*s.calls = append(*s.calls, s.name)
+}
+
+func TestShutdown__StopsInOrder(t *testing.T) {
+	mutex := &sync.Mutex{}
+	var calls []string
+	consumer := orderedShutdown{mutex: mutex, calls: &calls, name: "consumer"}
+	producers := []kafka.Producer{
+		orderedShutdown{mutex: mutex, calls: &calls, name: "producer"},
+		orderedShutdown{mutex: mutex, calls: &calls, name: "dead-letter producer"},
+	}
+
+	tracker := &messageTracker{}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := tracker.track(func(ctx context.Context, msg kafka.FTMessage) error {
+		close(started)
+		<-release
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls = append(calls, "message produced")
+		return nil
+	})
+	go handler(kafka.FTMessage{})
+	<-started
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	shutdown(consumer, producers, tracker, nil, &http.Server{}, time.Second)
+
+	assert.Equal(t, []string{"consumer", "message produced", "producer", "dead-letter producer"}, calls)
+	assert.Equal(t, errShuttingDown, handler(kafka.FTMessage{}))
+}
+
+// deliveringConsumer delivers a last message while it stops, as the Zookeeper consumer can
+type deliveringConsumer struct {
+	orderedShutdown
+	deliver func()
+}
+
+func (c deliveringConsumer) Shutdown() {
+	c.deliver()
+	c.orderedShutdown.Shutdown()
+}
+
+func TestShutdown__MapsTheMessagesTheZookeeperConsumerDeliversWhileStopping(t *testing.T) {
+	mutex := &sync.Mutex{}
+	var calls []string
+	tracker := &messageTracker{}
+	handler := tracker.track(func(ctx context.Context, msg kafka.FTMessage) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls = append(calls, "message produced")
+		return nil
+	})
+	var errDelivered error
+	consumer := deliveringConsumer{
+		orderedShutdown: orderedShutdown{mutex: mutex, calls: &calls, name: "consumer"},
+		deliver: func() {
+			errDelivered = handler(kafka.FTMessage{})
+		},
+	}
+
+	shutdown(consumer, nil, tracker, nil, &http.Server{}, time.Second)
+
+	assert.NoError(t, errDelivered, "the Zookeeper consumer commits the offset of refused messages")
+	assert.Equal(t, []string{"message produced", "consumer"}, calls)
+	assert.Equal(t, errShuttingDown, handler(kafka.FTMessage{}))
+}
+
+func TestConsumeClaim__LeavesMessagesRefusedOnShutdownUnmarked(t *testing.T) {
+	claim := mockConsumerGroupClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- &sarama.ConsumerMessage{Value: []byte("first"), Offset: 1}
+	claim.messages <- &sarama.ConsumerMessage{Value: []byte("second"), Offset: 2}
+	close(claim.messages)
+	session := &mockConsumerGroupSession{}
+
+	tracker := &messageTracker{}
+	handler := consumerGroupHandler{handle: handledOnReturn(tracker.track(func(ctx context.Context, msg kafka.FTMessage) error {
+		tracker.stop()
+		return nil
+	}))}
+
+	err := handler.ConsumeClaim(session, claim)
+
+	assert.NoError(t, err)
+	require.Len(t, session.marked, 1)
+	assert.Equal(t, int64(1), session.marked[0].Offset)
+}
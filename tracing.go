@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/go-logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The exporters the spans can be written with
+const (
+	noTracing     = "none"
+	otlpTracing   = "otlp"
+	stdoutTracing = "stdout"
+)
+
+const (
+	tracerName          = "github.com/Financial-Times/annotations-mapper"
+	defaultOTLPEndpoint = "localhost:4318"
+)
+
+// The attributes of the spans
+const (
+	transactionIDAttribute  = "transaction_id"
+	contentUUIDAttribute    = "content.uuid"
+	originSystemAttribute   = "origin_system_id"
+	metadataFormatAttribute = "metadata.format"
+	taxonomyAttribute       = "taxonomy"
+	annotationsAttribute    = "annotations.count"
+	problemsAttribute       = "validation.problems"
+	attemptsAttribute       = "produce.attempts"
+	skippedAttribute        = "skipped"
+)
+
+// newTracerProvider creates a tracer provider writing the spans with the exporter. It returns nil when tracing is disabled.
+func newTracerProvider(exporter string, otlpEndpoint string, otlpInsecure bool) (*sdktrace.TracerProvider, error) {
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case noTracing:
+		return nil, nil
+	case otlpTracing:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(otlpEndpoint)}
+		if otlpInsecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		spanExporter, err = otlptracehttp.New(context.Background(), options...)
+	case stdoutTracing:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, expected one of %s", exporter, strings.Join([]string{noTracing, otlpTracing, stdoutTracing}, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	), nil
+}
+
+// shutdownTracing writes the spans still buffered by the tracer provider before the service exits
+func shutdownTracing(provider *sdktrace.TracerProvider, timeout time.Duration) {
+	if provider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := provider.Shutdown(ctx); err != nil {
+		logger.Errorf(nil, err, "Error writing the remaining spans")
+	}
+}
+
+// defaultPropagator reads and writes the W3C trace context and baggage headers
+func defaultPropagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// headersCarrier carries the trace context in the headers of a queue message.
+// Header names are matched regardless of case, as the queue proxies do not keep it.
+type headersCarrier map[string]string
+
+func (c headersCarrier) Get(key string) string {
+	if value, found := c[key]; found {
+		return value
+	}
+	for name, value := range c {
+		if strings.EqualFold(name, key) {
+			return value
+		}
+	}
+	return ""
+}
+
+func (c headersCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+func (c headersCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// endSpan ends the span of a stage, marking it as failed when the stage returned an error
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/Shopify/sarama"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func newTracedTestMapper(producer kafka.Producer) (*Mapper, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	m := NewMapper(MapperDependencies{Producer: producer, Config: newDefaultConfigStore(), Tracer: provider.Tracer(tracerName)})
+	return m, recorder
+}
+
+func endedSpans(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	return spans
+}
+
+func TestMap__TracesEveryStage(t *testing.T) {
+	producer := &mockProducer{}
+	m, recorder := newTracedTestMapper(producer)
+	contentUUID := uuid.New()
+
+	require.NoError(t, m.Map(context.Background(), publishEventMessage(contentUUID, "tid_test")))
+
+	spans := endedSpans(recorder)
+	root, found := spans["map publish event"]
+	require.True(t, found)
+	assert.Equal(t, trace.SpanKindConsumer, root.SpanKind())
+	assert.False(t, root.Parent().IsValid())
+	assert.Equal(t, codes.Unset, root.Status().Code)
+	assert.Contains(t, root.Attributes(), attribute.String(contentUUIDAttribute, contentUUID))
+
+	for _, name := range []string{"unmarshal", "decode", "validate", "map taxonomies", "marshal", "produce"} {
+		span, found := spans[name]
+		require.True(t, found, name)
+		assert.Equal(t, root.SpanContext().SpanID(), span.Parent().SpanID(), name)
+		assert.Equal(t, root.SpanContext().TraceID(), span.SpanContext().TraceID(), name)
+	}
+	assert.Equal(t, trace.SpanKindProducer, spans["produce"].SpanKind())
+
+	taxonomies := m.config.current().taxonomies.Names()
+	for _, name := range taxonomies {
+		span, found := spans["map "+name]
+		require.True(t, found, name)
+		assert.Equal(t, spans["map taxonomies"].SpanContext().SpanID(), span.Parent().SpanID(), name)
+	}
+	assert.Len(t, recorder.Ended(), 7+len(taxonomies))
+}
+
+func TestMap__ContinuesTheTraceOfTheMessageHeaders(t *testing.T) {
+	producer := &mockProducer{}
+	m, recorder := newTracedTestMapper(producer)
+	msg := publishEventMessage(uuid.New(), "tid_test")
+	msg.Headers["Traceparent"] = testTraceparent
+
+	require.NoError(t, m.Map(context.Background(), msg))
+
+	root := endedSpans(recorder)["map publish event"]
+	require.NotNil(t, root)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", root.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", root.Parent().SpanID().String())
+	assert.True(t, root.Parent().IsRemote())
+}
+
+func TestMap__WritesTheTraceContextOfTheProduceSpan(t *testing.T) {
+	producer := &mockProducer{}
+	m, recorder := newTracedTestMapper(producer)
+	msg := publishEventMessage(uuid.New(), "tid_test")
+	msg.Headers["traceparent"] = testTraceparent
+
+	require.NoError(t, m.Map(context.Background(), msg))
+
+	produce := endedSpans(recorder)["produce"]
+	require.NotNil(t, produce)
+	require.Len(t, producer.messages, 1)
+	ctx := defaultPropagator().Extract(context.Background(), propagation.MapCarrier(producer.messages[0].Headers))
+	produced := trace.SpanContextFromContext(ctx)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", produced.TraceID().String())
+	assert.Equal(t, produce.SpanContext().SpanID(), produced.SpanID())
+}
+
+func TestMap__PassesOnTheTraceContextWhenTracingIsDisabled(t *testing.T) {
+	producer := &mockProducer{}
+	m := NewMapper(MapperDependencies{Producer: producer, Config: newDefaultConfigStore(), Tracer: trace.NewNoopTracerProvider().Tracer(tracerName)})
+	msg := publishEventMessage(uuid.New(), "tid_test")
+	msg.Headers["traceparent"] = testTraceparent
+
+	require.NoError(t, m.Map(context.Background(), msg))
+
+	require.Len(t, producer.messages, 1)
+	assert.Equal(t, testTraceparent, producer.messages[0].Headers["traceparent"])
+}
+
+func TestMap__FailedStagesAreMarkedAsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      kafka.FTMessage
+		err      error
+		stage    string
+		notEnded []string
+	}{
+		{
+			name:     "invalid json",
+			msg:      kafka.FTMessage{Headers: map[string]string{"Origin-System-Id": "http://cmdb.ft.com/systems/methode-web-pub"}, Body: "{"},
+			stage:    "unmarshal",
+			notEnded: []string{"decode", "produce"},
+		},
+		{
+			name:     "invalid base64",
+			msg:      kafka.FTMessage{Headers: map[string]string{"Origin-System-Id": "http://cmdb.ft.com/systems/methode-web-pub"}, Body: `{"uuid":"` + uuid.New() + `","value":"not base64!"}`},
+			stage:    "decode",
+			notEnded: []string{"validate", "produce"},
+		},
+		{
+			name:  "produce failure",
+			msg:   publishEventMessage(uuid.New(), "tid_test"),
+			err:   sarama.ErrMessageSizeTooLarge,
+			stage: "produce",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, recorder := newTracedTestMapper(&mockProducer{err: test.err})
+
+			require.Error(t, m.Map(context.Background(), test.msg))
+
+			spans := endedSpans(recorder)
+			require.Contains(t, spans, test.stage)
+			assert.Equal(t, codes.Error, spans[test.stage].Status().Code)
+			assert.Equal(t, codes.Error, spans["map publish event"].Status().Code)
+			for _, name := range test.notEnded {
+				assert.NotContains(t, spans, name)
+			}
+		})
+	}
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	provider, err := newTracerProvider(noTracing, defaultOTLPEndpoint, false)
+	assert.NoError(t, err)
+	assert.Nil(t, provider)
+
+	for _, exporter := range []string{otlpTracing, stdoutTracing} {
+		provider, err = newTracerProvider(exporter, defaultOTLPEndpoint, true)
+		assert.NoError(t, err, exporter)
+		require.NotNil(t, provider, exporter)
+		assert.NoError(t, provider.Shutdown(context.Background()), exporter)
+	}
+
+	_, err = newTracerProvider("jaeger", defaultOTLPEndpoint, false)
+	assert.Error(t, err)
+}
+
+func TestHeadersCarrier__GetIgnoresCase(t *testing.T) {
+	carrier := headersCarrier{"Traceparent": testTraceparent}
+
+	assert.Equal(t, testTraceparent, carrier.Get("traceparent"))
+	assert.Equal(t, "", carrier.Get("tracestate"))
+
+	carrier.Set("tracestate", "ft=1")
+	assert.ElementsMatch(t, []string{"Traceparent", "tracestate"}, carrier.Keys())
+}
@@ -8,12 +8,12 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestUnmarshalMetadata(t *testing.T) {
+func TestXMLMetadataDecoder__UTF8(t *testing.T) {
 
 	tests := []struct {
-		name               string
-		metadataBase64     string
-		expectInvalidChars bool
+		name           string
+		metadataBase64 string
+		expectError    bool
 	}{
 		{
 			"Should unmarshal metadata with VALID UTF8 characters",
@@ -28,14 +28,12 @@ func TestUnmarshalMetadata(t *testing.T) {
 
 	for _, test := range tests {
 		metadataXML, _ := base64.StdEncoding.DecodeString(test.metadataBase64)
-		_, err, hadInvalidChars := unmarshalMetadata(metadataXML)
-		if test.expectInvalidChars {
+		_, err := xmlMetadataDecoder{}.decode(metadataXML)
+		if test.expectError {
 			assert.NotNil(t, err, fmt.Sprintf("%s: Was expecting error, but got [%v].", test.name, err))
 		} else {
 			assert.Nil(t, err, fmt.Sprintf("%s: Was not expecting error, but got [%v].", test.name, err))
 		}
-		assert.Equal(t, test.expectInvalidChars, hadInvalidChars,
-			fmt.Sprintf("%s: Expected [%v] for expectInvalidChars, but got [%v].", test.name, test.expectInvalidChars, hadInvalidChars))
 	}
 }
 
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+)
+
+// The codes of the problems found by validating V1 metadata
+const (
+	emptyTermIDProblem          = "emptyTermId"
+	missingCanonicalNameProblem = "missingCanonicalName"
+	scoreOutOfRangeProblem      = "scoreOutOfRange"
+	unknownTaxonomyProblem      = "unknownTaxonomy"
+	duplicateTagProblem         = "duplicateTag"
+)
+
+// The validation policies decide what happens to messages with validation problems
+const (
+	rejectInvalidPolicy = "reject"
+	warnInvalidPolicy   = "warn"
+)
+
+// defaultIgnoredTaxonomies are V1 taxonomies that are not mapped, but are not reported as unknown either
+var defaultIgnoredTaxonomies = []string{"MediaTypes", "IPTC"}
+
+// validationProblem describes something wrong with the V1 metadata of a message
+type validationProblem struct {
+	Code    string `json:"code"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (p validationProblem) String() string {
+	return p.Field + ": " + p.Message
+}
+
+// validationError is returned for messages rejected because of validation problems
+type validationError struct {
+	problems []validationProblem
+}
+
+func (e validationError) Error() string {
+	return "metadata is not valid: " + strings.Join(describeProblems(e.problems), "; ")
+}
+
+func describeProblems(problems []validationProblem) []string {
+	descriptions := make([]string, len(problems))
+	for i, p := range problems {
+		descriptions[i] = p.String()
+	}
+	return descriptions
+}
+
+// metadataValidator checks V1 metadata for problems that parsing alone does not catch
+type metadataValidator struct {
+	knownTaxonomies map[string]bool
+}
+
+// newMetadataValidator creates a validator accepting the taxonomies of the mapping rules, with the other names of their primary
+// taxonomies, and the ignored taxonomies
+func newMetadataValidator(rules []mapper.TaxonomyRule, ignoredTaxonomies []string) metadataValidator {
+	known := make(map[string]bool, len(rules)+len(ignoredTaxonomies))
+	for _, rule := range rules {
+		known[strings.ToLower(rule.Taxonomy)] = true
+		if rule.Primary != nil {
+			for _, taxonomy := range rule.Primary.Taxonomies {
+				known[strings.ToLower(taxonomy)] = true
+			}
+		}
+	}
+	for _, taxonomy := range ignoredTaxonomies {
+		known[strings.ToLower(strings.TrimSpace(taxonomy))] = true
+	}
+	return metadataValidator{knownTaxonomies: known}
+}
+
+// validate returns the problems found in the metadata, or nothing if it is valid
+func (v metadataValidator) validate(contentRef mapper.ContentRef) []validationProblem {
+	var problems []validationProblem
+
+	tagPositions := make(map[string]int, len(contentRef.TagHolder.Tags))
+	for i, tag := range contentRef.TagHolder.Tags {
+		field := fmt.Sprintf("tags[%d]", i)
+		problems = append(problems, v.validateTerm(field+".term", tag.Term)...)
+		problems = append(problems, validateScore(field+".score.confidence", tag.TagScore.Confidence)...)
+		problems = append(problems, validateScore(field+".score.relevance", tag.TagScore.Relevance)...)
+
+		if tag.Term.ID == "" {
+			continue
+		}
+		key := strings.ToLower(tag.Term.Taxonomy) + " " + tag.Term.ID
+		if first, found := tagPositions[key]; found {
+			problems = append(problems, validationProblem{
+				Code:    duplicateTagProblem,
+				Field:   field,
+				Message: fmt.Sprintf("term %q is already tagged in tags[%d]", tag.Term.ID, first),
+			})
+			continue
+		}
+		tagPositions[key] = i
+	}
+
+	if contentRef.PrimarySection != (mapper.Term{}) {
+		problems = append(problems, v.validateTerm(mapper.PrimarySectionField, contentRef.PrimarySection)...)
+	}
+	if contentRef.PrimaryTheme != (mapper.Term{}) {
+		problems = append(problems, v.validateTerm(mapper.PrimaryThemeField, contentRef.PrimaryTheme)...)
+	}
+	return problems
+}
+
+func (v metadataValidator) validateTerm(field string, t mapper.Term) []validationProblem {
+	var problems []validationProblem
+	if strings.TrimSpace(t.ID) == "" {
+		problems = append(problems, validationProblem{Code: emptyTermIDProblem, Field: field + ".id", Message: "term has no id"})
+	}
+	if strings.TrimSpace(t.CanonicalName) == "" {
+		problems = append(problems, validationProblem{Code: missingCanonicalNameProblem, Field: field + ".canonicalName", Message: "term has no canonical name"})
+	}
+	if !v.knownTaxonomies[strings.ToLower(t.Taxonomy)] {
+		problems = append(problems, validationProblem{Code: unknownTaxonomyProblem, Field: field + ".taxonomy", Message: fmt.Sprintf("taxonomy %q is unknown", t.Taxonomy)})
+	}
+	return problems
+}
+
+func validateScore(field string, value int) []validationProblem {
+	if value < 0 || value > 100 {
+		return []validationProblem{{Code: scoreOutOfRangeProblem, Field: field, Message: fmt.Sprintf("score %d is not between 0 and 100", value)}}
+	}
+	return nil
+}
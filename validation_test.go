@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Financial-Times/annotations-mapper/mapper"
+	"github.com/stretchr/testify/assert"
+)
+
+func validTag(id string, taxonomy string) mapper.Tag {
+	return mapper.Tag{
+		Term:     mapper.Term{ID: id, CanonicalName: "Canonical name of " + id, Taxonomy: taxonomy},
+		TagScore: mapper.TagScore{Confidence: 90, Relevance: 90},
+	}
+}
+
+func TestValidate__ValidMetadata(t *testing.T) {
+	v := newMetadataValidator(mapper.DefaultTaxonomyRules, defaultIgnoredTaxonomies)
+	metadata := mapper.ContentRef{
+		TagHolder:      mapper.Tags{Tags: []mapper.Tag{validTag("1", "Subjects"), validTag("2", "ON"), validTag("3", "MediaTypes")}},
+		PrimarySection: mapper.Term{ID: "4", CanonicalName: "World", Taxonomy: "Sections"},
+	}
+
+	assert.Empty(t, v.validate(metadata))
+}
+
+func TestValidate__Problems(t *testing.T) {
+	v := newMetadataValidator(mapper.DefaultTaxonomyRules, defaultIgnoredTaxonomies)
+
+	emptyID := validTag("", "Subjects")
+	noName := validTag("1", "Subjects")
+	noName.Term.CanonicalName = " "
+	highScore := validTag("1", "Subjects")
+	highScore.TagScore.Relevance = 101
+	negativeScore := validTag("1", "Subjects")
+	negativeScore.TagScore.Confidence = -1
+
+	tests := []struct {
+		name     string
+		metadata mapper.ContentRef
+		expected []validationProblem
+	}{
+		{
+			"Empty term ID",
+			mapper.ContentRef{TagHolder: mapper.Tags{Tags: []mapper.Tag{emptyID}}},
+			[]validationProblem{{Code: emptyTermIDProblem, Field: "tags[0].term.id", Message: "term has no id"}},
+		},
+		{
+			"Missing canonical name",
+			mapper.ContentRef{TagHolder: mapper.Tags{Tags: []mapper.Tag{noName}}},
+			[]validationProblem{{Code: missingCanonicalNameProblem, Field: "tags[0].term.canonicalName", Message: "term has no canonical name"}},
+		},
+		{
+			"Relevance above 100",
+			mapper.ContentRef{TagHolder: mapper.Tags{Tags: []mapper.Tag{highScore}}},
+			[]validationProblem{{Code: scoreOutOfRangeProblem, Field: "tags[0].score.relevance", Message: "score 101 is not between 0 and 100"}},
+		},
+		{
+			"Negative confidence",
+			mapper.ContentRef{TagHolder: mapper.Tags{Tags: []mapper.Tag{negativeScore}}},
+			[]validationProblem{{Code: scoreOutOfRangeProblem, Field: "tags[0].score.confidence", Message: "score -1 is not between 0 and 100"}},
+		},
+		{
+			"Unknown taxonomy",
+			mapper.ContentRef{TagHolder: mapper.Tags{Tags: []mapper.Tag{validTag("1", "Unicorns")}}},
+			[]validationProblem{{Code: unknownTaxonomyProblem, Field: "tags[0].term.taxonomy", Message: `taxonomy "Unicorns" is unknown`}},
+		},
+		{
+			"Duplicate tag",
+			mapper.ContentRef{TagHolder: mapper.Tags{Tags: []mapper.Tag{validTag("1", "Subjects"), validTag("2", "Subjects"), validTag("1", "subjects")}}},
+			[]validationProblem{{Code: duplicateTagProblem, Field: "tags[2]", Message: `term "1" is already tagged in tags[0]`}},
+		},
+		{
+			"Invalid primary theme",
+			mapper.ContentRef{PrimaryTheme: mapper.Term{ID: "1", Taxonomy: "Topics"}},
+			[]validationProblem{{Code: missingCanonicalNameProblem, Field: "primaryTheme.canonicalName", Message: "term has no canonical name"}},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, v.validate(test.metadata), test.name)
+	}
+}
+
+func TestValidate__OtherNamesOfPrimaryTaxonomiesAreKnown(t *testing.T) {
+	v := newMetadataValidator(mapper.DefaultTaxonomyRules, defaultIgnoredTaxonomies)
+
+	for _, taxonomy := range []string{"Organisations", "people"} {
+		metadata := mapper.ContentRef{PrimaryTheme: mapper.Term{ID: "1", CanonicalName: "Canonical name of 1", Taxonomy: taxonomy}}
+		assert.Empty(t, v.validate(metadata), taxonomy)
+	}
+}
+
+func TestValidate__IgnoredTaxonomiesAreConfigurable(t *testing.T) {
+	v := newMetadataValidator(mapper.DefaultTaxonomyRules, []string{" unicorns "})
+	metadata := mapper.ContentRef{TagHolder: mapper.Tags{Tags: []mapper.Tag{validTag("1", "Unicorns"), validTag("2", "MediaTypes")}}}
+
+	problems := v.validate(metadata)
+
+	assert.Equal(t, []validationProblem{{Code: unknownTaxonomyProblem, Field: "tags[1].term.taxonomy", Message: `taxonomy "MediaTypes" is unknown`}}, problems)
+}
+
+func TestValidationError(t *testing.T) {
+	err := validationError{problems: []validationProblem{
+		{Code: emptyTermIDProblem, Field: "tags[0].term.id", Message: "term has no id"},
+		{Code: unknownTaxonomyProblem, Field: "tags[1].term.taxonomy", Message: `taxonomy "Unicorns" is unknown`},
+	}}
+
+	assert.EqualError(t, err, `metadata is not valid: tags[0].term.id: term has no id; tags[1].term.taxonomy: taxonomy "Unicorns" is unknown`)
+}
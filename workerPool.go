@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/kafka-client-go/kafka"
+)
+
+// workerQueueSize is how many messages can wait for each worker, so that a slow message does not stop the consumer
+// from handing messages to the other workers straight away
+const workerQueueSize = 8
+
+type poolTask struct {
+	ctx  context.Context
+	msg  kafka.FTMessage
+	done func()
+}
+
+// workerPool handles messages in parallel. All the messages of a content UUID go to the same worker,
+// so that their annotations are still produced in the order the messages were consumed.
+type workerPool struct {
+	queues  []chan poolTask
+	handler func(ctx context.Context, msg kafka.FTMessage) error
+	workers sync.WaitGroup
+}
+
+func newWorkerPool(concurrency int, handler func(ctx context.Context, msg kafka.FTMessage) error) *workerPool {
+	p := &workerPool{queues: make([]chan poolTask, concurrency), handler: handler}
+	for i := range p.queues {
+		p.queues[i] = make(chan poolTask, workerQueueSize)
+		p.workers.Add(1)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+func (p *workerPool) work(queue chan poolTask) {
+	defer p.workers.Done()
+	for task := range queue {
+		_ = p.handler(task.ctx, task.msg)
+		task.done()
+	}
+}
+
+// submit queues the message on the worker of its content UUID, waiting if that worker is full.
+// The worker handles it with the context, and done is called once the message is handled.
+func (p *workerPool) submit(ctx context.Context, msg kafka.FTMessage, done func()) {
+	p.queues[p.workerIndex(msg)] <- poolTask{ctx: ctx, msg: msg, done: done}
+}
+
+// trackedHandler returns the handler given to the broker consumer, which tracks every message until its worker has handled it,
+// and only then tells the consumer that the message is handled, so that its offset is not committed before it is mapped.
+// The time the message was consumed is passed on to the worker, so that its lag includes the time it waited in the queue.
+// The messages are handled with the context of the tracker, so that the shutdown can stop their produce retries.
+func (p *workerPool) trackedHandler(tracker *messageTracker) asyncHandler {
+	return func(msg kafka.FTMessage, handled func()) error {
+		ctx, ok := tracker.begin()
+		if !ok {
+			return errShuttingDown
+		}
+		p.submit(withConsumedAt(ctx, time.Now()), msg, func() {
+			handled()
+			tracker.inFlight.Done()
+		})
+		return nil
+	}
+}
+
+func (p *workerPool) workerIndex(msg kafka.FTMessage) int {
+	// messages without a readable UUID all go to the same worker, where the mapper reports them
+	var event struct {
+		UUID string `json:"uuid"`
+	}
+	_ = json.Unmarshal([]byte(msg.Body), &event)
+
+	h := fnv.New32a()
+	h.Write([]byte(event.UUID))
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
+
+// close stops the workers once the queued messages are handled. Nothing can be submitted afterwards.
+func (p *workerPool) close() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.workers.Wait()
+}
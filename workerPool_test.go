@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/kafka-client-go/kafka"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publishEventMessage(contentUUID string, tid string) kafka.FTMessage {
+	return kafka.FTMessage{
+		Headers: map[string]string{
+			"Origin-System-Id": "http://cmdb.ft.com/systems/methode-web-pub",
+			"X-Request-Id":     tid,
+		},
+		Body: `{"uuid":"` + contentUUID + `","value":"` + validUTF8Metadata + `"}`,
+	}
+}
+
+func TestWorkerPool__KeepsTheOrderOfEachUUID(t *testing.T) {
+	var mutex sync.Mutex
+	handled := map[string][]string{}
+	pool := newWorkerPool(4, func(ctx context.Context, msg kafka.FTMessage) error {
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+		var event MetadataPublishEvent
+		require.NoError(t, json.Unmarshal([]byte(msg.Body), &event))
+		mutex.Lock()
+		defer mutex.Unlock()
+		handled[event.UUID] = append(handled[event.UUID], msg.Headers["X-Request-Id"])
+		return nil
+	})
+
+	contentUUIDs := make([]string, 10)
+	for i := range contentUUIDs {
+		contentUUIDs[i] = uuid.New()
+	}
+	var expected = map[string][]string{}
+	for i := 0; i < 20; i++ {
+		for _, contentUUID := range contentUUIDs {
+			tid := fmt.Sprintf("tid_%d", i)
+			expected[contentUUID] = append(expected[contentUUID], tid)
+			pool.submit(context.Background(), publishEventMessage(contentUUID, tid), func() {})
+		}
+	}
+	pool.close()
+
+	assert.Equal(t, expected, handled)
+}
+
+func TestWorkerPool__SlowContentDoesNotBlockOtherContent(t *testing.T) {
+	pool := newWorkerPool(4, func(ctx context.Context, msg kafka.FTMessage) error { return nil })
+	slowUUID := uuid.New()
+	otherUUID := uuid.New()
+	for pool.workerIndex(publishEventMessage(otherUUID, "")) == pool.workerIndex(publishEventMessage(slowUUID, "")) {
+		otherUUID = uuid.New()
+	}
+	pool.close()
+
+	otherHandled := make(chan struct{})
+	pool = newWorkerPool(4, func(ctx context.Context, msg kafka.FTMessage) error {
+		if msg.Headers["X-Request-Id"] == "tid_slow" {
+			<-otherHandled
+		}
+		return nil
+	})
+	pool.submit(context.Background(), publishEventMessage(slowUUID, "tid_slow"), func() {})
+	pool.submit(context.Background(), publishEventMessage(otherUUID, "tid_other"), func() { close(otherHandled) })
+
+	select {
+	case <-otherHandled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the other content waited for the slow content")
+	}
+	pool.close()
+}
+
+func TestWorkerPool__TrackedHandler(t *testing.T) {
+	tracker := &messageTracker{}
+	release := make(chan struct{})
+	pool := newWorkerPool(2, func(ctx context.Context, msg kafka.FTMessage) error {
+		<-release
+		return nil
+	})
+	handler := pool.trackedHandler(tracker)
+	handled := make(chan struct{})
+
+	require.NoError(t, handler(publishEventMessage(uuid.New(), "tid_test"), func() { close(handled) }))
+	tracker.stop()
+	assert.Equal(t, errShuttingDown, handler(publishEventMessage(uuid.New(), "tid_test"), func() { t.Error("a refused message was reported as handled") }))
+	assert.False(t, tracker.wait(time.Now().Add(50*time.Millisecond)), "the message is still being handled by the pool")
+	select {
+	case <-handled:
+		t.Fatal("the message was reported as handled before its worker mapped it")
+	default:
+	}
+
+	close(release)
+	assert.True(t, tracker.wait(time.Now().Add(time.Second)))
+	<-handled
+	pool.close()
+}
+
+func TestWorkerPool__TrackedHandlerIsCancelledByTheTracker(t *testing.T) {
+	tracker := &messageTracker{}
+	pool := newWorkerPool(2, func(ctx context.Context, msg kafka.FTMessage) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	handled := make(chan struct{})
+
+	require.NoError(t, pool.trackedHandler(tracker)(publishEventMessage(uuid.New(), "tid_test"), func() { close(handled) }))
+	tracker.abandon()
+
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("the worker was not cancelled")
+	}
+	pool.close()
+}
+
+// slowProducer simulates the round trip to the brokers, which is where most of the time of a message is spent
+type slowProducer struct {
+	latency time.Duration
+	sent    int64
+}
+
+func (p *slowProducer) SendMessage(message kafka.FTMessage) error {
+	time.Sleep(p.latency)
+	atomic.AddInt64(&p.sent, 1)
+	return nil
+}
+
+func (p *slowProducer) ConnectivityCheck() error {
+	return nil
+}
+
+func (p *slowProducer) Shutdown() {}
+
+func benchmarkMessages(n int) []kafka.FTMessage {
+	contentUUIDs := make([]string, 64)
+	for i := range contentUUIDs {
+		contentUUIDs[i] = uuid.New()
+	}
+	msgs := make([]kafka.FTMessage, n)
+	for i := range msgs {
+		msgs[i] = publishEventMessage(contentUUIDs[i%len(contentUUIDs)], fmt.Sprintf("tid_benchmark_%d", i))
+	}
+	return msgs
+}
+
+func BenchmarkHandleMessage_Sequential(b *testing.B) {
+	mapper := newTestMapper(&slowProducer{latency: time.Millisecond})
+	msgs := benchmarkMessages(b.N)
+	tracker := &messageTracker{}
+	handler := tracker.track(mapper.Map)
+
+	b.ResetTimer()
+	for _, msg := range msgs {
+		handler(msg)
+	}
+	tracker.wait(time.Now().Add(time.Hour))
+	b.StopTimer()
+}
+
+func benchmarkWorkerPool(b *testing.B, concurrency int) {
+	mapper := newTestMapper(&slowProducer{latency: time.Millisecond})
+	msgs := benchmarkMessages(b.N)
+	tracker := &messageTracker{}
+	pool := newWorkerPool(concurrency, mapper.Map)
+	handler := pool.trackedHandler(tracker)
+
+	b.ResetTimer()
+	for _, msg := range msgs {
+		handler(msg, func() {})
+	}
+	tracker.wait(time.Now().Add(time.Hour))
+	b.StopTimer()
+	pool.close()
+}
+
+func BenchmarkHandleMessage_WorkerPool4(b *testing.B) {
+	benchmarkWorkerPool(b, 4)
+}
+
+func BenchmarkHandleMessage_WorkerPool16(b *testing.B) {
+	benchmarkWorkerPool(b, 16)
+}